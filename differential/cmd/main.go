@@ -3,19 +3,29 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"math"
+	"math/rand"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/mariiatuzovska/cryptanalysis/differential"
 	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/mariiatuzovska/cryptanalysis/internal/cmdutil"
+	"github.com/mariiatuzovska/cryptanalysis/progress"
+	"github.com/mariiatuzovska/cryptanalysis/search"
+	"github.com/mariiatuzovska/cryptanalysis/stats"
 	"github.com/urfave/cli"
 )
 
 var (
-	alpha       = 0xc00
-	beta        = 0x1111
+	alpha       = heys.Block(0xc00)
+	beta        = heys.Block(0x1111)
 	limKeyCount = 5
 	keyFiles    = []string{
 		"community/keys_attack_0x0c00_0x8888.json",
@@ -38,61 +48,488 @@ func main() {
 	app.Version = "0.0.1"
 	app.Copyright = "2020, mariiatuzovska"
 	app.Authors = []cli.Author{cli.Author{Name: "Tuzovska Mariia"}}
+	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:  "spec",
+			Usage: "JSON cipher specification with S-box, permutation, round keys and rounds, Heys cipher if empty",
+		},
+	}
 	app.Commands = []cli.Command{
 		{
 			Name:  "e",
 			Usage: "encrypt",
+			Flags: cmdutil.DataFlags("community/plain.txt", "community/cipher.txt"),
 			Action: func(c *cli.Context) error {
-				data, err := ioutil.ReadFile("community/plain.txt")
+				cipher, iv, err := cmdutil.CipherFromFlags(c)
+				if err != nil {
+					return err
+				}
+				in, err := os.Open(c.String("in"))
+				if err != nil {
+					return err
+				}
+				defer in.Close()
+				out, err := os.Create(c.String("out"))
 				if err != nil {
 					return err
 				}
-				blocks := heys.ConvertDataToBlocks(data)
-				for i := 0; i < len(blocks); i++ {
-					blocks[i] = heys.Encrypt(blocks[i])
+				w, err := heys.NewEncryptWriter(out, cipher, c.String("mode"), iv)
+				if err != nil {
+					out.Close()
+					return err
 				}
-				data = heys.ConvertBlocksToData(blocks)
-				key := heys.ConvertBlocksToData(heys.Defaultkey)
-				ioutil.WriteFile("community/key.txt", key, os.ModePerm)
-				return ioutil.WriteFile("community/cipher.txt", data, os.ModePerm)
+				if _, err = io.Copy(w, in); err != nil {
+					out.Close()
+					return err
+				}
+				return w.Close()
 			},
 		},
 		{
 			Name:  "d",
 			Usage: "decrypt",
+			Flags: cmdutil.DataFlags("community/cipher.txt", "community/pt2.txt"),
 			Action: func(c *cli.Context) error {
-				data, err := ioutil.ReadFile("community/ct2.txt")
+				cipher, iv, err := cmdutil.CipherFromFlags(c)
 				if err != nil {
 					return err
 				}
-				blocks := heys.ConvertDataToBlocks(data)
-				for i := 0; i < len(blocks); i++ {
-					blocks[i] = heys.Decrypt(blocks[i])
+				in, err := os.Open(c.String("in"))
+				if err != nil {
+					return err
 				}
-				data = heys.ConvertBlocksToData(blocks)
-				key := heys.ConvertBlocksToData(heys.Defaultkey)
-				ioutil.WriteFile("community/key.txt", key, os.ModePerm)
-				return ioutil.WriteFile("community/pt2.txt", data, os.ModePerm)
+				defer in.Close()
+				r, err := heys.NewDecryptReader(in, cipher, c.String("mode"), iv)
+				if err != nil {
+					return err
+				}
+				out, err := os.Create(c.String("out"))
+				if err != nil {
+					return err
+				}
+				if _, err = io.Copy(out, r); err != nil {
+					out.Close()
+					return err
+				}
+				return out.Close()
+			},
+		},
+		{
+			Name:  "master",
+			Usage: "recovers master key with key schedule of --spec from round key or from keys found by attack",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "key",
+					Usage: "hex round key, candidates in --keys are checked with community/encrypted.txt if empty",
+				},
+				&cli.IntFlag{
+					Name:  "round",
+					Value: -1,
+					Usage: "index of round key from 0, the last round key found by attack if negative",
+				},
+				&cli.StringFlag{
+					Name:  "keys",
+					Value: fmt.Sprintf("community/keys_attack_0x%04x_0x%04x.json", alpha, beta),
+					Usage: "round keys scored by attack",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				spec, err := cmdutil.LoadSpec(c)
+				if err != nil {
+					return err
+				}
+				spn, err := spec.SPN()
+				if err != nil {
+					return err
+				}
+				schedule, err := spec.KeySchedule()
+				if err != nil {
+					return err
+				}
+				inverter, ok := schedule.(heys.KeyScheduleInverter)
+				if !ok {
+					return fmt.Errorf("%T can't recover master key", schedule)
+				}
+				round := c.Int("round")
+				if round < 0 {
+					round = spn.Rounds()
+				}
+				var master, keys []heys.Key
+				if c.String("key") != "" {
+					key, err := strconv.ParseUint(strings.TrimPrefix(c.String("key"), "0x"), 16, 16)
+					if err != nil {
+						return err
+					}
+					if master, err = inverter.Master(spn, round, heys.Key(key)); err != nil {
+						return err
+					}
+					if keys, err = schedule.Expand(spn, master); err != nil {
+						return err
+					}
+				} else {
+					scores := make(map[heys.Key]int)
+					file, err := ioutil.ReadFile(c.String("keys"))
+					if err != nil {
+						return err
+					}
+					if err = json.Unmarshal(file, &scores); err != nil {
+						return err
+					}
+					encrypted, err := cmdutil.ReadEncrypted("community/encrypted.txt")
+					if err != nil {
+						return err
+					}
+					if master, keys, err = heys.RecoverMaster(spn, inverter, spec.SBox, round, cmdutil.Candidates(scores), encrypted); err != nil {
+						return err
+					}
+				}
+				for _, k := range master {
+					fmt.Println(fmt.Sprintf("master 0x%04x", k))
+				}
+				for i, k := range keys {
+					fmt.Println(fmt.Sprintf("round key #%d 0x%04x", i, k))
+				}
+				return nil
 			},
 		},
 		{
 			Name:  "search",
 			Usage: "search for defferentials",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "alphas",
+					Usage: "comma separated hex input differences, differences of one S-box if empty",
+				},
+				&cli.StringFlag{
+					Name:  "thresholds",
+					Usage: "comma separated min probabilities after every round, the last one is used for remaining rounds",
+				},
+				&cli.IntFlag{
+					Name:  "rounds",
+					Usage: "count of rounds, rounds of cipher minus the last one if 0",
+				},
+				&cli.IntFlag{
+					Name:  "workers",
+					Usage: "count of alphas searched concurrently, count of CPU if 0",
+				},
+				&cli.StringFlag{
+					Name:  "checkpoint",
+					Usage: "file where state is saved to resume after interruption, no checkpoints if empty",
+					Value: "community/differences.checkpoint.json",
+				},
+				&cli.DurationFlag{
+					Name:  "checkpoint-interval",
+					Value: time.Minute,
+				},
+				&cli.BoolFlag{
+					Name:  "resume",
+					Usage: "continues from --checkpoint",
+				},
+				&cli.IntFlag{
+					Name:  "trails",
+					Usage: "count of the most probable trails of every differential written to community/trails.json",
+				},
+			},
 			Action: func(c *cli.Context) error {
 				// d := differential.NewDifferential(heys.NewHeys(&key))
-				m := differential.Search()
+				cipher, err := cmdutil.LoadCipher(c)
+				if err != nil {
+					return err
+				}
+				opts := differential.SearchOptions{
+					Options: search.Options{
+						Cipher:             cipher,
+						Rounds:             c.Int("rounds"),
+						Workers:            c.Int("workers"),
+						Checkpoint:         c.String("checkpoint"),
+						CheckpointInterval: c.Duration("checkpoint-interval"),
+						Resume:             c.Bool("resume"),
+					},
+					Trails: c.Int("trails"),
+				}
+				if opts.Alphas, err = cmdutil.ParseBlocks(c.String("alphas")); err != nil {
+					return err
+				}
+				if opts.Thresholds, err = cmdutil.ParseFloats(c.String("thresholds")); err != nil {
+					return err
+				}
+				ctx, cancel := progress.InterruptContext()
+				defer cancel()
+				opts.Progress = progress.Bar(os.Stderr, 40)
+				t1 := time.Now()
+				differentials, err := differential.SearchTrails(ctx, opts)
+				if err != nil {
+					return cmdutil.Interrupted(err)
+				}
+				fmt.Println("Runs", time.Since(t1).Milliseconds(), "ms")
+				m := make(map[heys.Block]map[heys.Block]float64)
+				for a, bMap := range differentials {
+					m[a] = make(map[heys.Block]float64)
+					for b, d := range bMap {
+						m[a][b] = d.Probability
+					}
+				}
 				arr, err := json.MarshalIndent(m, "", "	")
 				if err != nil {
 					log.Fatal(err)
 				}
-				return ioutil.WriteFile("community/differences.json", arr, os.ModePerm)
+				if err = ioutil.WriteFile("community/differences.json", arr, os.ModePerm); err != nil {
+					return err
+				}
+				if opts.Trails == 0 {
+					return nil
+				}
+				arr, err = json.MarshalIndent(differentials, "", "	")
+				if err != nil {
+					return err
+				}
+				return ioutil.WriteFile("community/trails.json", arr, os.ModePerm)
+			},
+		},
+		{
+			Name:  "trails",
+			Usage: "shows trails of differential alpha and beta in community/trails.json",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "alpha",
+					Value: "0400",
+				},
+				&cli.StringFlag{
+					Name:  "beta",
+					Value: "1111",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				blocks, err := cmdutil.ParseBlocks(c.String("alpha") + "," + c.String("beta"))
+				if err != nil {
+					return err
+				}
+				differentials := make(map[heys.Block]map[heys.Block]differential.Differential)
+				file, err := ioutil.ReadFile("community/trails.json")
+				if err != nil {
+					return err
+				}
+				if err = json.Unmarshal(file, &differentials); err != nil {
+					return err
+				}
+				d, exist := differentials[blocks[0]][blocks[1]]
+				if !exist {
+					return fmt.Errorf("differential 0x%04x : 0x%04x has not been found", blocks[0], blocks[1])
+				}
+				fmt.Println(fmt.Sprintf("0x%04x : 0x%04x -- %f -- %d trails", blocks[0], blocks[1], d.Probability, len(d.Trails)))
+				for i, t := range d.Trails {
+					fmt.Println(fmt.Sprintf("trail #%d -- %f", i+1, t.Probability))
+					for r := range t.Probabilities {
+						fmt.Println(fmt.Sprintf("  round %d 0x%04x -> 0x%04x -- %d active S-boxes -- %f",
+							r+1, t.Differences[r], t.Differences[r+1], t.Active[r], t.Probabilities[r]))
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "best-trail",
+			Usage: "finds the most probable trail by Matsui branch and bound search over S-box DDT",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:  "rounds",
+					Usage: "count of rounds, rounds of cipher minus the last one if 0",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				spec, err := cmdutil.LoadSpec(c)
+				if err != nil {
+					return err
+				}
+				spn, err := spec.SPN()
+				if err != nil {
+					return err
+				}
+				rounds := c.Int("rounds")
+				if rounds == 0 {
+					rounds = spn.Rounds() - 1
+				}
+				ctx, cancel := progress.InterruptContext()
+				defer cancel()
+				t1 := time.Now()
+				trail, bounds, err := differential.BestTrail(ctx, spn, spec.SBox, rounds)
+				if err != nil {
+					return cmdutil.Interrupted(err)
+				}
+				fmt.Println("Runs", time.Since(t1).Milliseconds(), "ms")
+				for r, w := range bounds {
+					fmt.Println(fmt.Sprintf("best trail of %d rounds -- 2^-%.2f", r+1, w))
+				}
+				fmt.Println(fmt.Sprintf("0x%04x : 0x%04x -- %f", trail.Differences[0], trail.Differences[rounds], trail.Probability))
+				for r := range trail.Probabilities {
+					fmt.Println(fmt.Sprintf("  round %d 0x%04x -> 0x%04x -- %d active S-boxes -- %f",
+						r+1, trail.Differences[r], trail.Differences[r+1], trail.Active[r], trail.Probabilities[r]))
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "cluster",
+			Usage: "sums trails of differential alpha and beta and compares it with community/differences.json and the best trail",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "alpha",
+					Usage: "hex input difference, all differentials in community/differences.json if empty",
+				},
+				&cli.StringFlag{
+					Name:  "beta",
+					Usage: "hex output difference",
+				},
+				&cli.IntFlag{
+					Name:  "rounds",
+					Usage: "count of rounds, rounds of cipher minus the last one if 0",
+				},
+				&cli.Float64Flag{
+					Name:  "weight",
+					Value: 24,
+					Usage: "max weight of trails, -log2 of their probability",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				spec, err := cmdutil.LoadSpec(c)
+				if err != nil {
+					return err
+				}
+				spn, err := spec.SPN()
+				if err != nil {
+					return err
+				}
+				rounds := c.Int("rounds")
+				if rounds == 0 {
+					rounds = spn.Rounds() - 1
+				}
+				differentials := make(map[heys.Block]map[heys.Block]float64)
+				file, err := ioutil.ReadFile("community/differences.json")
+				if err != nil && (c.String("alpha") == "" || !os.IsNotExist(err)) {
+					return err
+				}
+				if err == nil {
+					if err = json.Unmarshal(file, &differentials); err != nil {
+						return err
+					}
+				}
+				pairs := [][2]heys.Block{}
+				if c.String("alpha") != "" {
+					blocks, err := cmdutil.ParseBlocks(c.String("alpha") + "," + c.String("beta"))
+					if err != nil {
+						return err
+					}
+					pairs = append(pairs, [2]heys.Block{blocks[0], blocks[1]})
+				} else {
+					for a, bMap := range differentials {
+						for b := range bMap {
+							pairs = append(pairs, [2]heys.Block{a, b})
+						}
+					}
+					sort.Slice(pairs, func(i, j int) bool {
+						return differentials[pairs[i][0]][pairs[i][1]] > differentials[pairs[j][0]][pairs[j][1]]
+					})
+				}
+				ctx, cancel := progress.InterruptContext()
+				defer cancel()
+				t1 := time.Now()
+				for _, pair := range pairs {
+					cluster, err := differential.SumTrails(ctx, spn, spec.SBox, rounds, pair[0], pair[1], math.Exp2(-c.Float64("weight")))
+					if err != nil {
+						return cmdutil.Interrupted(err)
+					}
+					fmt.Println(fmt.Sprintf("0x%04x : 0x%04x -- search %f -- cluster %f of %d trails -- best trail %f",
+						pair[0], pair[1], differentials[pair[0]][pair[1]], cluster.Probability, cluster.Trails, cluster.Best.Probability))
+				}
+				fmt.Println("Runs", time.Since(t1).Milliseconds(), "ms")
+				return nil
+			},
+		},
+		{
+			Name:  "verify",
+			Usage: "measures probability of differential alpha and beta on rounds of cipher with random keys",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "alpha",
+					Value: "0400",
+				},
+				&cli.StringFlag{
+					Name:  "beta",
+					Value: "1111",
+				},
+				&cli.IntFlag{
+					Name:  "rounds",
+					Usage: "count of rounds, rounds of cipher minus the last one if 0",
+				},
+				&cli.IntFlag{
+					Name:  "keys",
+					Value: stats.Keys,
+					Usage: "count of random keys",
+				},
+				&cli.IntFlag{
+					Name:  "texts",
+					Usage: "count of random plaintext pairs of every key, all pairs if 0",
+				},
+				&cli.Int64Flag{
+					Name:  "seed",
+					Value: 1,
+				},
+			},
+			Action: func(c *cli.Context) error {
+				blocks, err := cmdutil.ParseBlocks(c.String("alpha") + "," + c.String("beta"))
+				if err != nil {
+					return err
+				}
+				cipher, err := cmdutil.LoadCipher(c)
+				if err != nil {
+					return err
+				}
+				rounds := c.Int("rounds")
+				if rounds == 0 {
+					rounds = cipher.Rounds() - 1
+				}
+				differentials := make(map[heys.Block]map[heys.Block]float64)
+				file, err := ioutil.ReadFile("community/differences.json")
+				if err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				if err == nil {
+					if err = json.Unmarshal(file, &differentials); err != nil {
+						return err
+					}
+				}
+				ctx, cancel := progress.InterruptContext()
+				defer cancel()
+				cluster, err := differential.SumTrails(ctx, cipher.SPN(), cipher.SBox(), rounds, blocks[0], blocks[1], math.Exp2(-24))
+				if err != nil {
+					return cmdutil.Interrupted(err)
+				}
+				opts := differential.VerifyOptions{
+					Cipher:   cipher,
+					Rounds:   rounds,
+					Keys:     c.Int("keys"),
+					Texts:    c.Int("texts"),
+					Rand:     rand.New(rand.NewSource(c.Int64("seed"))),
+					Progress: progress.Bar(os.Stderr, 40),
+				}
+				t1 := time.Now()
+				v, err := differential.Verify(ctx, blocks[0], blocks[1], opts)
+				if err != nil {
+					return cmdutil.Interrupted(err)
+				}
+				fmt.Println("Runs", time.Since(t1).Milliseconds(), "ms")
+				fmt.Println(fmt.Sprintf("0x%04x : 0x%04x -- search %f -- cluster %f of %d trails -- best trail %f",
+					blocks[0], blocks[1], differentials[blocks[0]][blocks[1]], cluster.Probability, cluster.Trails, cluster.Best.Probability))
+				fmt.Println(fmt.Sprintf("measured %f -- 95%% interval [%f, %f] -- keys from %f to %f -- %d keys of %d pairs",
+					v.Probability, v.Low, v.High, v.Min, v.Max, v.Keys, v.Pairs))
+				return nil
 			},
 		},
 		{
 			Name:  "show",
 			Usage: "shows defferentials that has been found",
 			Action: func(c *cli.Context) error {
-				dPTable := make(map[int]map[int]float64)
+				dPTable := make(map[heys.Block]map[heys.Block]float64)
 				file, err := ioutil.ReadFile("community/differences.json")
 				if err != nil {
 					return err
@@ -115,7 +552,24 @@ func main() {
 			Name:  "attack",
 			Usage: "finds keys for differentials alpha and beta",
 			Action: func(c *cli.Context) error {
-				m := differential.Attack(alpha, beta)
+				cipher, err := cmdutil.LoadCipher(c)
+				if err != nil {
+					return err
+				}
+				encrypted, err := cmdutil.ReadEncrypted("community/encrypted.txt")
+				if err != nil {
+					return err
+				}
+				ctx, cancel := progress.InterruptContext()
+				defer cancel()
+				fmt.Println(fmt.Sprintf("Attack for input differences 0x%04x : 0x%04x", alpha, beta))
+				m, err := differential.Attack(ctx, alpha, beta, encrypted, differential.AttackOptions{
+					Cipher:   cipher,
+					Progress: progress.Bar(os.Stderr, 40),
+				})
+				if err != nil {
+					return cmdutil.Interrupted(err)
+				}
 				arr, err := json.MarshalIndent(m, "", "	")
 				if err != nil {
 					log.Fatal(err)
@@ -127,7 +581,7 @@ func main() {
 			Name:  "attack-all",
 			Usage: "finds keys for all differentials alpha and beta in community/differentials.json",
 			Action: func(c *cli.Context) error {
-				dPTable := make(map[int]map[int]float64)
+				dPTable := make(map[heys.Block]map[heys.Block]float64)
 				file, err := ioutil.ReadFile("community/differences.json")
 				if err != nil {
 					return err
@@ -136,12 +590,28 @@ func main() {
 				if err != nil {
 					return err
 				}
+				cipher, err := cmdutil.LoadCipher(c)
+				if err != nil {
+					return err
+				}
+				encrypted, err := cmdutil.ReadEncrypted("community/encrypted.txt")
+				if err != nil {
+					return err
+				}
+				ctx, cancel := progress.InterruptContext()
+				defer cancel()
 				for a, bMap := range dPTable {
 					for b := range bMap {
 						if 0x000f&b != 0 && 0x00f0&b != 0 && 0x0f00&b != 0 && 0xf000&b != 0 {
 							pathToFile := fmt.Sprintf("community/keys_attack_0x%04x_0x%04x.json", a, b)
 							fmt.Println(pathToFile)
-							m := differential.Attack(a, b)
+							m, err := differential.Attack(ctx, a, b, encrypted, differential.AttackOptions{
+								Cipher:   cipher,
+								Progress: progress.Bar(os.Stderr, 40),
+							})
+							if err != nil {
+								return cmdutil.Interrupted(err)
+							}
 							arr, err := json.MarshalIndent(m, "", "	")
 							if err != nil {
 								log.Fatal(err)
@@ -160,7 +630,7 @@ func main() {
 			Name:  "report",
 			Usage: "shows beautiful report about differential cryptanacysis of heys cipher",
 			Action: func(c *cli.Context) error {
-				DPTMap := make(map[int]map[int]float64)
+				DPTMap := make(map[heys.Block]map[heys.Block]float64)
 				file, err := ioutil.ReadFile("community/differences.json")
 				if err != nil {
 					return err
@@ -169,20 +639,20 @@ func main() {
 				if err != nil {
 					return err
 				}
-				fmt.Println("\nFound differences:\n")
+				fmt.Print("\nFound differences:\n\n")
 				sortKeysDPTable := make([]int, 0)
 				sortedDiffProbs := make([]float64, 0)
-				sortedDiffMap := make(map[float64]int)
+				sortedDiffMap := make(map[float64]heys.Block)
 				for key := range DPTMap {
 					if len(DPTMap[key]) > 0 {
-						sortKeysDPTable = append(sortKeysDPTable, key)
+						sortKeysDPTable = append(sortKeysDPTable, int(key))
 					}
 				}
 				sort.Ints(sortKeysDPTable)
 				for _, a := range sortKeysDPTable {
-					differences := DPTMap[a]
+					differences := DPTMap[heys.Block(a)]
 					sortedDiffProbs = make([]float64, 0)
-					sortedDiffMap = make(map[float64]int)
+					sortedDiffMap = make(map[float64]heys.Block)
 					for b, prob := range differences {
 						if 0x000f&b != 0 && 0x00f0&b != 0 && 0x0f00&b != 0 && 0xf000&b != 0 {
 							sortedDiffProbs = append(sortedDiffProbs, prob)
@@ -196,10 +666,10 @@ func main() {
 
 				}
 				// keys
-				keys := make(map[uint16]int)
+				keys := make(map[heys.Key]int)
 				for _, fPath := range keyFiles {
 					fmt.Println("\nRead file:", fPath)
-					k := make(map[uint16]int)
+					k := make(map[heys.Key]int)
 					file, err := ioutil.ReadFile(fPath)
 					if err != nil {
 						log.Fatal(err)
@@ -209,7 +679,7 @@ func main() {
 						log.Fatal(err)
 					}
 					sorted := []int{}
-					sortedMap := make(map[int]uint16)
+					sortedMap := make(map[int]heys.Key)
 					for key, count := range k {
 						if _, exist := keys[key]; exist {
 							keys[key] = keys[key] + count
@@ -227,7 +697,7 @@ func main() {
 				}
 				fmt.Println(fmt.Sprintf("\n\nSUM for all keys\n"))
 				sorted := []int{}
-				sortedMap := make(map[int]uint16)
+				sortedMap := make(map[int]heys.Key)
 				for Key, count := range keys {
 					if count > limKeyCount {
 						sorted = append(sorted, count)
@@ -251,7 +721,7 @@ func main() {
 				},
 			},
 			Action: func(c *cli.Context) error {
-				keys := make(map[uint16]int)
+				keys := make(map[heys.Key]int)
 				var fileName string
 				if c.String("file") != "" {
 					fileName = c.String("file")
@@ -279,9 +749,9 @@ func main() {
 			Name:  "key-found-all",
 			Usage: "shows keys and their probability for all differentials that has been processed",
 			Action: func(c *cli.Context) error {
-				keys := make(map[uint16]int)
+				keys := make(map[heys.Key]int)
 				for _, fPath := range keyFiles {
-					k := make(map[uint16]int)
+					k := make(map[heys.Key]int)
 					file, err := ioutil.ReadFile(fPath)
 					if err != nil {
 						log.Fatal(err)
@@ -301,7 +771,7 @@ func main() {
 					}
 				}
 				sorted := []int{}
-				sortedMap := make(map[int]uint16)
+				sortedMap := make(map[int]heys.Key)
 				for Key, count := range keys {
 					if count > limKeyCount {
 						sorted = append(sorted, count)
@@ -317,5 +787,7 @@ func main() {
 		},
 	}
 
-	app.Run(os.Args)
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -1,24 +1,26 @@
 package differential
 
 import (
+	"context"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"math/rand"
 	"runtime"
 	"sync"
-	"time"
 
 	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/mariiatuzovska/cryptanalysis/progress"
+	"github.com/mariiatuzovska/cryptanalysis/sbox"
+	"github.com/mariiatuzovska/cryptanalysis/search"
 )
 
 type (
 	differenceResponse struct {
-		alpha       int
-		probability map[int]float64
+		alpha       heys.Block
+		probability map[heys.Block]float64
+		trails      map[heys.Block][]Trail
 	}
 	keyResponse struct {
-		key         int
+		key         heys.Key
 		concurrency int
 	}
 )
@@ -27,56 +29,96 @@ var (
 	limValues     = []float64{0.1, 0.0001, 0.0001, 0.00005, 0.001}
 	limConcurency = 10
 	countOfText   = 16000
-	alphas        = []int{
-		0x0001, 0x0002, 0x0003, 0x0004, 0x0005, 0x0006, 0x0007, 0x0008, 0x0009, 0x000a, 0x000b, 0x000c, 0x000d, 0x000e, 0x000f,
-		0x0010, 0x0020, 0x0030, 0x0040, 0x0050, 0x0060, 0x0070, 0x0080, 0x0090, 0x00a0, 0x00b0, 0x00c0, 0x00d0, 0x00e0, 0x00f0,
-		0x0100, 0x0200, 0x0300, 0x0400, 0x0500, 0x0600, 0x0700, 0x0800, 0x0900, 0x0a00, 0x0b00, 0x0c00, 0x0d00, 0x0e00, 0x0f00,
-		0x1000, 0x2000, 0x3000, 0x4000, 0x5000, 0x6000, 0x7000, 0x8000, 0x9000, 0xa000, 0xb000, 0xc000, 0xd000, 0xe000, 0xf000}
 )
 
-func Attack(alpha int, beta int) map[int]int {
+// AttackOptions are parameters of Attack, zero fields take default values
+type AttackOptions struct {
+	// Cipher which last round is decrypted, heys.DefaultCipher() if nil
+	Cipher *heys.Cipher
+	// Texts is count of chosen plaintexts, countOfText if zero
+	Texts int
+	// Threshold is min count of right pairs of a key in result, limConcurency
+	// if zero
+	Threshold int
+	// Rand chooses plaintexts, source of math/rand package if nil
+	Rand *rand.Rand
+	// Progress receives count of tested keys if not nil
+	Progress progress.Func
+}
 
-	t1 := time.Now()
+// withDefaults returns copy of options with default values of zero fields
+func (opts AttackOptions) withDefaults() (AttackOptions, error) {
+	if opts.Cipher == nil {
+		opts.Cipher = heys.DefaultCipher()
+	}
+	if opts.Texts == 0 {
+		opts.Texts = countOfText
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = limConcurency
+	}
+	if opts.Texts < 1 || opts.Texts > opts.Cipher.Size() {
+		return opts, fmt.Errorf("differential: invalid count of texts %d for %d-bit block", opts.Texts, opts.Cipher.SPN().BlockSize())
+	}
+	return opts, nil
+}
 
-	texts, decrypted := make(map[int]bool), heys.DecryptAll()
-	if countOfText > 0xf000 {
-		for i := 0; i < countOfText; i++ {
-			texts[i] = true
-		}
-	} else {
-		for i := 0; i < countOfText; i++ {
-			x := rand.Int() & 0xffff
-			if _, exist := texts[x]; !exist {
-				// if 0x000f&x != 0 && 0x00f0&x != 0 && 0x0f00&x != 0 && 0xf000&x != 0 {
-				texts[x] = true
-				i++
-				// }
-			}
-			i--
-		}
+func (opts AttackOptions) intn(n int) int {
+	if opts.Rand == nil {
+		return rand.Intn(n)
 	}
+	return opts.Rand.Intn(n)
+}
+
+// Attack counts right pairs of plaintexts x and x^alpha for every last round
+// key: pairs which ciphertexts are decrypted by one round to difference beta,
+// encrypted[x] is ciphertext of plaintext x, keys with more than
+// opts.Threshold right pairs are returned
+func Attack(ctx context.Context, alpha heys.Block, beta heys.Block, encrypted []heys.Block, opts AttackOptions) (map[heys.Key]int, error) {
 
-	// encrypted := heys.EncryptAllWithKey()
-	data, err := ioutil.ReadFile("community/encrypted.txt")
+	opts, err := opts.withDefaults()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	size := opts.Cipher.Size()
+	if len(encrypted) != size {
+		return nil, fmt.Errorf("differential: %d encrypted blocks, expected %d", len(encrypted), size)
+	}
+	for x, y := range encrypted {
+		if int(y) >= size {
+			return nil, fmt.Errorf("differential: ciphertext 0x%04x of plaintext 0x%04x is out of %d-bit block", y, x, opts.Cipher.SPN().BlockSize())
+		}
+	}
+	if int(alpha) >= size || int(beta) >= size {
+		return nil, fmt.Errorf("differential: differential 0x%04x : 0x%04x is out of %d-bit block", alpha, beta, opts.Cipher.SPN().BlockSize())
+	}
+	texts, decrypted := make(map[heys.Block]bool), opts.Cipher.DecryptRoundAll()
+	if opts.Texts > size*15/16 {
+		for i := 0; i < opts.Texts; i++ {
+			texts[heys.Block(i)] = true
+		}
+	} else {
+		for len(texts) < opts.Texts {
+			texts[heys.Block(opts.intn(size))] = true
+		}
 	}
-	encrypted := heys.ConvertDataToBlocks(data)
 
 	numCPU := runtime.NumCPU()
 	runtime.GOMAXPROCS(numCPU)
-	responseChan := make(chan keyResponse, 0x10000)
-
-	result := make(map[int]int)
+	responseChan := make(chan keyResponse, size)
+	tracker := progress.NewTracker(opts.Progress, "attack", size)
 
-	fmt.Println(fmt.Sprintf("Attack for input differences 0x%04x : 0x%04x", alpha, beta))
+	result := make(map[heys.Key]int)
 
-	for key := 0; key < 0x10000; key++ {
-		go func(resp chan keyResponse, txts map[int]bool, enc, dec []int, probablyKey, a, b int) {
+	for key := 0; key < size; key++ {
+		go func(resp chan keyResponse, txts map[heys.Block]bool, enc, dec []heys.Block, probablyKey heys.Key, a, b heys.Block) {
+			if ctx.Err() != nil {
+				return
+			}
 			concurrency := 0
 			for block := range txts {
 				c1, c2 := enc[block], enc[block^a]
-				if dec[c1^probablyKey]^dec[c2^probablyKey] == b {
+				if dec[c1^heys.Block(probablyKey)]^dec[c2^heys.Block(probablyKey)] == b {
 					concurrency++
 				}
 			}
@@ -84,125 +126,339 @@ func Attack(alpha int, beta int) map[int]int {
 				key:         probablyKey,
 				concurrency: concurrency,
 			}
-		}(responseChan, texts, encrypted, decrypted, key, alpha, beta)
+		}(responseChan, texts, encrypted, decrypted, heys.Key(key), alpha, beta)
 	}
 
 	mutex := sync.Mutex{}
-	for x := 0; x < 0x10000; x++ {
-		response := <-responseChan
+	for x := 0; x < size; x++ {
+		var response keyResponse
+		select {
+		case response = <-responseChan:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 		mutex.Lock()
-		if response.concurrency > limConcurency {
+		if response.concurrency > opts.Threshold {
 			result[response.key] = response.concurrency
 			// fmt.Println(fmt.Sprintf("key 0x%04x concurency %d", key, maxConcurency))
 		}
 		mutex.Unlock()
+		tracker.Add(0, 0, 1)
 	}
 
-	t2 := time.Now().Sub(t1)
-	fmt.Println("Runs", t2.Milliseconds(), "ms")
+	return result, nil
+}
 
-	return result
+// SearchOptions are parameters of Search, zero fields take default values,
+// alphas are input differences and thresholds are min probabilities of
+// differentials after every round, limValues if empty
+type SearchOptions struct {
+	search.Options
+	// Trails is count of the most probable trails kept for every
+	// differential by SearchTrails, no trails if zero
+	Trails int
 }
 
-func Search() *map[int]map[int]float64 {
+// terms name input differences and probabilities in errors of search
+var terms = search.Terms{Input: "input difference", Measure: "probability"}
 
-	t1 := time.Now()
+// withDefaults returns copy of options with default values of zero fields
+func (opts SearchOptions) withDefaults() (SearchOptions, error) {
+	if opts.Trails < 0 {
+		return opts, fmt.Errorf("differential: invalid count of trails %d", opts.Trails)
+	}
+	var err error
+	opts.Options, err = opts.Options.WithDefaults(limValues, terms)
+	return opts, err
+}
 
-	result := make(map[int]map[int]float64)
-	encrypted := heys.EncryptAll()
+// Search returns probabilities of differentials from every alpha of opts
+// over opts.Rounds rounds. Probability of differential is sum of
+// probabilities of all its trails which differences after every round are
+// not below thresholds, so it estimates cluster of trails of differential
+// that SumTrails enumerates for one alpha and beta
+func Search(ctx context.Context, opts SearchOptions) (*map[heys.Block]map[heys.Block]float64, error) {
+	opts.Trails = 0
+	differentials, err := SearchTrails(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[heys.Block]map[heys.Block]float64)
+	for alpha, differential := range differentials {
+		result[alpha] = make(map[heys.Block]float64)
+		for beta, d := range differential {
+			result[alpha][beta] = d.Probability
+		}
+	}
+	return &result, nil
+}
 
-	numCPU := runtime.NumCPU()
-	runtime.GOMAXPROCS(numCPU)
-	responseChan := make(chan differenceResponse, len(alphas))
+// SearchTrails is Search that keeps opts.Trails the most probable trails of
+// every differential
+func SearchTrails(ctx context.Context, opts SearchOptions) (map[heys.Block]map[heys.Block]Differential, error) {
 
-	for _, alpha := range alphas {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	result, size := make(map[heys.Block]map[heys.Block]Differential), opts.Cipher.Size()
+	s, err := sbox.New(opts.Cipher.SBox())
+	if err != nil {
+		return nil, err
+	}
+	spn, ddt := opts.Cipher.SPN(), s.DDT()
+	state, err := loadSearchCheckpoint(opts)
+	if err != nil {
+		return nil, err
+	}
+	saver := search.NewSaver(opts.Options, state)
+	defer saver.Stop()
+
+	runtime.GOMAXPROCS(runtime.NumCPU())
+	alphaChan := make(chan heys.Block, len(opts.Alphas))
+	responseChan := make(chan differenceResponse, len(opts.Alphas))
+	tracker := progress.NewTracker(opts.Progress, "search", len(opts.Alphas)*opts.Rounds)
+	rounds, alphas := 0, len(state.Done)
+	for alpha, res := range state.Done {
+		result[alpha] = differentials(res, state.DoneTrails[alpha])
+		rounds += opts.Rounds
+	}
+	for _, g := range state.Gamma {
+		rounds += g.Round
+		if g.Round == opts.Rounds {
+			alphas++
+		}
+	}
+	tracker.Resume(rounds, alphas, 0)
+	for _, alpha := range opts.Alphas {
+		if _, done := state.Done[alpha]; !done {
+			alphaChan <- alpha
+		}
+	}
+	close(alphaChan)
 
-		go func(a int, resp chan differenceResponse, enc []int) {
+	for w := 0; w < opts.Workers; w++ {
 
-			gamma := make([]float64, 0x10000)
-			for x := 0; x < 0x10000; x++ {
-				gamma[x] = -1.0
-			}
-			gamma[a] = 1.0
-			for round := 1; round < 6; round++ {
-				g := make([]float64, 0x10000)
-				for x := 0; x < 0x10000; x++ {
-					g[x] = -1.0
+		go func(alphas chan heys.Block, resp chan differenceResponse) {
+
+			for a := range alphas {
+
+				gamma := make([]float64, size)
+				for x := 0; x < size; x++ {
+					gamma[x] = -1.0
 				}
-				for diff := 0; diff < 0x10000; diff++ {
-					dProb := gamma[diff]
-					if dProb < 0.0 {
-						continue
+				gamma[a] = 1.0
+				var trails [][]Trail
+				if opts.Trails > 0 {
+					trails = make([][]Trail, size)
+					trails[a] = []Trail{newTrail(a)}
+				}
+				saver.Lock()
+				saved, resumed := state.Gamma[a]
+				savedTrails := state.GammaTrails[a]
+				saver.Unlock()
+				if resumed {
+					gamma[a] = -1.0
+					for x, p := range saved.Gamma {
+						gamma[x] = p
 					}
-					probs := differentialPropability(diff, enc)
-					for x := 0; x < 0x10000; x++ {
-						if probs[x] > -1.0 {
-							currentProb := g[x]
-							if currentProb < 0.0 {
-								currentProb = 0.0
-							}
-							g[x] = currentProb + (probs[x] * dProb)
+					if opts.Trails > 0 {
+						trails[a] = nil
+						for x, t := range savedTrails {
+							trails[x] = t
 						}
 					}
 				}
-				for x := 0; x < 0x10000; x++ {
-					if g[x] < limValues[round-1] {
+				for round := saved.Round + 1; round <= opts.Rounds; round++ {
+					if ctx.Err() != nil {
+						return
+					}
+					g := make([]float64, size)
+					for x := 0; x < size; x++ {
 						g[x] = -1.0
 					}
+					var next [][]Trail
+					if opts.Trails > 0 {
+						next = make([][]Trail, size)
+					}
+					for diff := 0; diff < size; diff++ {
+						dProb := gamma[diff]
+						if dProb < 0.0 {
+							continue
+						}
+						if diff&0xfff == 0 && ctx.Err() != nil {
+							return
+						}
+						probs := differentialPropability(heys.Block(diff), spn, ddt)
+						for x := 0; x < size; x++ {
+							if probs[x] > -1.0 {
+								currentProb := g[x]
+								if currentProb < 0.0 {
+									currentProb = 0.0
+								}
+								g[x] = currentProb + (probs[x] * dProb)
+								if trails == nil {
+									continue
+								}
+								for _, t := range trails[diff] {
+									if accepts(next[x], t.Probability*probs[x], opts.Trails) {
+										next[x] = insert(next[x], t.extend(spn, heys.Block(x), probs[x]), opts.Trails)
+									}
+								}
+							}
+						}
+					}
+					for x := 0; x < size; x++ {
+						if g[x] < opts.Threshold(round) {
+							g[x] = -1.0
+							if next != nil {
+								next[x] = nil
+							}
+						}
+					}
+					for x := 0; x < size; x++ {
+						gamma[x] = g[x]
+					}
+					trails = next
+					saver.Lock()
+					state.Gamma[a] = search.Gamma{Round: round, Gamma: sparse(gamma)}
+					if trails != nil {
+						state.GammaTrails[a] = sparseTrails(trails)
+					}
+					saver.Unlock()
+					if round == opts.Rounds {
+						tracker.Add(1, 1, 0)
+					} else {
+						tracker.Add(1, 0, 0)
+					}
 				}
-				for x := 0; x < 0x10000; x++ {
-					gamma[x] = g[x]
-				}
-			}
 
-			res := make(map[int]float64)
-			for x := 0; x < 0x10000; x++ {
-				if gamma[x] > -1.0 {
-					res[x] = gamma[x]
+				resp <- differenceResponse{
+					alpha:       a,
+					probability: sparse(gamma),
+					trails:      sparseTrails(trails),
 				}
 			}
 
-			resp <- differenceResponse{
-				alpha:       a,
-				probability: res,
+		}(alphaChan, responseChan)
+
+	}
+
+	for len(result) < len(opts.Alphas) {
+		select {
+		case response := <-responseChan:
+			saver.Lock()
+			result[response.alpha] = differentials(response.probability, response.trails)
+			state.Done[response.alpha] = response.probability
+			if response.trails != nil {
+				state.DoneTrails[response.alpha] = response.trails
+			}
+			delete(state.Gamma, response.alpha)
+			delete(state.GammaTrails, response.alpha)
+			saver.Unlock()
+		case <-saver.C:
+			if err := saver.Save(); err != nil {
+				return nil, err
 			}
+		case <-ctx.Done():
+			return nil, saver.Cancel(ctx)
+		}
+	}
+	if err := saver.Remove(); err != nil {
+		return nil, err
+	}
 
-			fmt.Println(fmt.Sprintf("alpha 0x%04x has %d betas", a, len(res)))
+	return result, nil
+}
 
-		}(alpha, responseChan, encrypted)
+// searchCheckpoint is state of Search in checkpoint file with trails of
+// differentials
+type searchCheckpoint struct {
+	*search.State
+	// DoneTrails are trails of differentials of finished alphas
+	DoneTrails map[heys.Block]map[heys.Block][]Trail `json:"done_trails"`
+	// GammaTrails are trails of differentials of unfinished alphas after
+	// some rounds
+	GammaTrails map[heys.Block]map[heys.Block][]Trail `json:"gamma_trails"`
+}
 
+// loadSearchCheckpoint returns state of search with opts, it is loaded from
+// checkpoint file if opts.Resume is set and the file exists
+func loadSearchCheckpoint(opts SearchOptions) (*searchCheckpoint, error) {
+	state := &searchCheckpoint{
+		State:       search.NewState(opts.Options, opts.Trails),
+		DoneTrails:  make(map[heys.Block]map[heys.Block][]Trail),
+		GammaTrails: make(map[heys.Block]map[heys.Block][]Trail),
 	}
-
-	mutex := sync.Mutex{}
-	for i := 0; i < len(alphas); i++ {
-		response := <-responseChan
-		mutex.Lock()
-		result[response.alpha] = response.probability
-		mutex.Unlock()
+	if err := state.Load(opts.Options, state); err != nil {
+		return nil, err
 	}
+	if state.DoneTrails == nil {
+		state.DoneTrails = make(map[heys.Block]map[heys.Block][]Trail)
+	}
+	if state.GammaTrails == nil {
+		state.GammaTrails = make(map[heys.Block]map[heys.Block][]Trail)
+	}
+	return state, nil
+}
 
-	close(responseChan)
-
-	t2 := time.Now().Sub(t1)
-	fmt.Println("Runs", t2.Milliseconds(), "ms")
+// sparseTrails returns nonempty entries of trails, nil if trails are not kept
+func sparseTrails(trails [][]Trail) map[heys.Block][]Trail {
+	if trails == nil {
+		return nil
+	}
+	res := make(map[heys.Block][]Trail)
+	for x := range trails {
+		if len(trails[x]) != 0 {
+			res[heys.Block(x)] = trails[x]
+		}
+	}
+	return res
+}
 
-	return &result
+// differentials joins probabilities and trails of output differences
+func differentials(probability map[heys.Block]float64, trails map[heys.Block][]Trail) map[heys.Block]Differential {
+	res := make(map[heys.Block]Differential)
+	for beta, p := range probability {
+		res[beta] = Differential{Probability: p, Trails: trails[beta]}
+	}
+	return res
 }
 
-func differentialPropability(alpha int, encrypted []int) []float64 {
-	frequence := make([]int, 0x10000)
-	for x := 0; x < 0x10000; x++ {
-		frequence[x] = 0
+// sparse returns nonnegative entries of gamma
+func sparse(gamma []float64) map[heys.Block]float64 {
+	res := make(map[heys.Block]float64)
+	for x := range gamma {
+		if gamma[x] > -1.0 {
+			res[heys.Block(x)] = gamma[x]
+		}
 	}
-	for x := 0; x < 0x10000; x++ {
-		frequence[encrypted[x]^encrypted[x^alpha]]++
+	return res
+}
+
+// differentialPropability returns probabilities of output differences of one
+// round for input difference alpha as products of DDT entries of S-boxes
+func differentialPropability(alpha heys.Block, spn *heys.SPN, ddt [][]int) []float64 {
+	size := 1 << uint(spn.BlockSize())
+	frequence := map[int]int{0: 1}
+	for i := 0; i < spn.Count(); i++ {
+		a, shift := spn.Nibble(int(alpha), i), uint(i*spn.Width())
+		next := make(map[int]int)
+		for block, count := range frequence {
+			for b, n := range ddt[a] {
+				if n != 0 {
+					next[block|b<<shift] = count * n
+				}
+			}
+		}
+		frequence = next
 	}
-	probability := make([]float64, 0x10000)
-	for x := 0; x < 0x10000; x++ {
+	probability := make([]float64, size)
+	for x := 0; x < size; x++ {
 		probability[x] = -1.0
-		if frequence[x] != 0 {
-			probability[x] = (float64(frequence[x]) / float64(0x10000))
-		}
+	}
+	for block, count := range frequence {
+		probability[spn.Permute(block)] = (float64(count) / float64(size))
 	}
 	return probability
 }
@@ -0,0 +1,91 @@
+package differential
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/mariiatuzovska/cryptanalysis/matsui"
+	"github.com/mariiatuzovska/cryptanalysis/sbox"
+)
+
+// Cluster is probability of differential estimated as sum of probabilities
+// of its trails that are not less than cutoff of SumTrails
+type Cluster struct {
+	Probability float64 `json:"probability"`
+	// Trails is count of trails in Probability
+	Trails int `json:"trails"`
+	// Best is the most probable trail of differential
+	Best Trail `json:"best"`
+}
+
+// BestTrail returns the most probable differential trail over rounds of spn
+// with sBox and weights of the best trails over 1, ..., rounds rounds, weight
+// is -log2 of probability. The trail is found by Matsui branch and bound
+// search over S-box DDT
+func BestTrail(ctx context.Context, spn *heys.SPN, sBox []int, rounds int) (Trail, []float64, error) {
+	table, err := transitions(spn, sBox)
+	if err != nil {
+		return Trail{}, nil, err
+	}
+	path, bounds, err := matsui.Search(ctx, spn, table, rounds)
+	if err != nil {
+		return Trail{}, nil, err
+	}
+	return pathTrail(spn, path), bounds, nil
+}
+
+// SumTrails returns cluster of differential alpha, beta over rounds of spn
+// with sBox: all its trails with probability not less than cutoff are
+// enumerated over S-box DDT. Search estimates the same sum for all betas at
+// once by pruning differences below thresholds after every round instead of
+// trails below cutoff
+func SumTrails(ctx context.Context, spn *heys.SPN, sBox []int, rounds int, alpha, beta heys.Block, cutoff float64) (Cluster, error) {
+	if cutoff <= 0 || cutoff > 1 {
+		return Cluster{}, fmt.Errorf("differential: invalid cutoff %v", cutoff)
+	}
+	table, err := transitions(spn, sBox)
+	if err != nil {
+		return Cluster{}, err
+	}
+	c, err := matsui.Sum(ctx, spn, table, rounds, int(alpha), int(beta), -math.Log2(cutoff))
+	if err != nil {
+		return Cluster{}, err
+	}
+	cluster := Cluster{Probability: c.Sum, Trails: c.Paths}
+	if c.Paths != 0 {
+		cluster.Best = pathTrail(spn, c.Best)
+	}
+	return cluster, nil
+}
+
+// transitions returns transitions of S-box DDT with weights -log2 of their
+// probabilities
+func transitions(spn *heys.SPN, sBox []int) ([][]matsui.Transition, error) {
+	s, err := sbox.New(sBox)
+	if err != nil {
+		return nil, err
+	}
+	if s.Bits() != spn.Width() {
+		return nil, fmt.Errorf("differential: %d-bit S-box for %d-bit S-boxes of SPN", s.Bits(), spn.Width())
+	}
+	table := make([][]matsui.Transition, s.Size())
+	for a, row := range s.DDT() {
+		for b, n := range row {
+			if n != 0 {
+				table[a] = append(table[a], matsui.Transition{Output: b, Weight: math.Log2(float64(s.Size()) / float64(n))})
+			}
+		}
+	}
+	return table, nil
+}
+
+// pathTrail returns trail of path of differences
+func pathTrail(spn *heys.SPN, path matsui.Path) Trail {
+	t := newTrail(heys.Block(path.Inputs[0]))
+	for r := range path.Outputs {
+		t = t.extend(spn, heys.Block(spn.Permute(path.Outputs[r])), math.Exp2(-path.Weights[r]))
+	}
+	return t
+}
@@ -0,0 +1,134 @@
+package differential
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/mariiatuzovska/cryptanalysis/sbox"
+)
+
+// small is 3-bit S-box which DDT has entries 2 and 4
+var small = []int{1, 0, 2, 3, 4, 6, 5, 7}
+
+// permutation moves bit j of S-box i to bit j+1 of S-box i+j modulo 3, the
+// last bit of S-box is not kept in the last bit of any S-box
+var permutation = []int{1, 5, 6, 4, 8, 0, 7, 2, 3}
+
+// smallSPN returns SPN of three 3-bit S-boxes and DDT of small S-box
+func smallSPN(t *testing.T) (*heys.SPN, [][]int) {
+	t.Helper()
+	spn, err := heys.NewSPN(3, 3, 3, permutation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := sbox.New(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spn, s.DDT()
+}
+
+// enumerate calls visit with probability of every trail over rounds of spn
+// from alpha by DDT and its output difference, nothing is pruned
+func enumerate(spn *heys.SPN, ddt [][]int, rounds int, alpha heys.Block, visit func(p float64, beta heys.Block)) {
+	size := float64(len(ddt))
+	var substitute func(r, i, x, y int, p float64)
+	substitute = func(r, i, x, y int, p float64) {
+		if i == spn.Count() {
+			if r < rounds-1 {
+				substitute(r+1, 0, spn.Permute(y), 0, p)
+				return
+			}
+			visit(p, heys.Block(spn.Permute(y)))
+			return
+		}
+		a := spn.Nibble(x, i)
+		for b, n := range ddt[a] {
+			if n != 0 {
+				substitute(r, i+1, x, y|b<<uint(i*spn.Width()), p*float64(n)/size)
+			}
+		}
+	}
+	substitute(0, 0, int(alpha), 0, 1)
+}
+
+func TestBestTrail(t *testing.T) {
+	spn, ddt := smallSPN(t)
+	rounds := spn.Rounds()
+	// best[r] is probability of the most probable trail over r+1 rounds
+	best := make([]float64, rounds)
+	for r := range best {
+		for alpha := 1; alpha < 1<<uint(spn.BlockSize()); alpha++ {
+			enumerate(spn, ddt, r+1, heys.Block(alpha), func(p float64, beta heys.Block) {
+				best[r] = math.Max(best[r], p)
+			})
+		}
+	}
+	trail, bounds, err := BestTrail(context.Background(), spn, small, rounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r := range bounds {
+		if math.Abs(math.Exp2(-bounds[r])-best[r]) > 1e-12 {
+			t.Fatalf("bound of %d rounds is 2^-%v, enumeration gives %v", r+1, bounds[r], best[r])
+		}
+	}
+	if len(trail.Differences) != rounds+1 || math.Abs(trail.Probability-best[rounds-1]) > 1e-12 {
+		t.Fatalf("best trail %+v, enumeration gives probability %v", trail, best[rounds-1])
+	}
+	// rounds of trail are DDT transitions of its differences
+	for r, p := range trail.Probabilities {
+		x, y, want, active := int(trail.Differences[r]), spn.InversePermute(int(trail.Differences[r+1])), 1.0, 0
+		for i := 0; i < spn.Count(); i++ {
+			if a := spn.Nibble(x, i); a != 0 {
+				want *= float64(ddt[a][spn.Nibble(y, i)]) / float64(len(ddt))
+				active++
+			}
+		}
+		if p != want || trail.Active[r] != active {
+			t.Fatalf("round %d of trail %+v has probability %v of %d S-boxes, DDT gives %v of %d", r+1, trail, p, trail.Active[r], want, active)
+		}
+	}
+	if _, _, err := BestTrail(context.Background(), spn, heys.SBlocks, rounds); err == nil {
+		t.Error("4-bit S-box is accepted for 3-bit S-boxes")
+	}
+}
+
+func TestSumTrails(t *testing.T) {
+	spn, ddt := smallSPN(t)
+	rounds := spn.Rounds()
+	for _, alpha := range spn.SingleSBoxBlocks() {
+		// all trails are above the first cutoff, the second cuts some
+		for _, cutoff := range []float64{1e-9, 1.0 / 16} {
+			want, count, best := make(map[heys.Block]float64), make(map[heys.Block]int), make(map[heys.Block]float64)
+			enumerate(spn, ddt, rounds, alpha, func(p float64, beta heys.Block) {
+				if p >= cutoff {
+					want[beta] += p
+					count[beta]++
+					best[beta] = math.Max(best[beta], p)
+				}
+			})
+			total := 0.0
+			for beta := heys.Block(1); int(beta) < 1<<uint(spn.BlockSize()); beta++ {
+				cluster, err := SumTrails(context.Background(), spn, small, rounds, alpha, beta, cutoff)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cluster.Trails != count[beta] || math.Abs(cluster.Probability-want[beta]) > 1e-12 || cluster.Best.Probability != best[beta] {
+					t.Fatalf("cutoff %v: cluster of 0x%03x, 0x%03x is %+v, enumeration gives %d trails of %v, the best %v",
+						cutoff, alpha, beta, cluster, count[beta], want[beta], best[beta])
+				}
+				total += cluster.Probability
+			}
+			// differentials of alpha of Markov cipher sum to one
+			if cutoff < 1e-6 && math.Abs(total-1) > 1e-9 {
+				t.Fatalf("differentials of 0x%03x sum to %v", alpha, total)
+			}
+		}
+	}
+	if _, err := SumTrails(context.Background(), spn, small, rounds, 1, 1, 0); err == nil {
+		t.Error("zero cutoff is accepted")
+	}
+}
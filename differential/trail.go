@@ -0,0 +1,72 @@
+package differential
+
+import "github.com/mariiatuzovska/cryptanalysis/heys"
+
+type (
+	// Trail is differential characteristic: Differences[i] is input difference
+	// of round i+1 and the last one is output difference, Active[i] is count
+	// of active S-boxes and Probabilities[i] is probability of round i+1
+	Trail struct {
+		Differences   []heys.Block `json:"differences"`
+		Active        []int        `json:"active"`
+		Probabilities []float64    `json:"probabilities"`
+		Probability   float64      `json:"probability"`
+	}
+	// Differential is probability of differential accumulated over trails and
+	// the most probable trails of differential
+	Differential struct {
+		Probability float64 `json:"probability"`
+		Trails      []Trail `json:"trails,omitempty"`
+	}
+)
+
+// newTrail returns trail of zero rounds with input difference alpha
+func newTrail(alpha heys.Block) Trail {
+	return Trail{
+		Differences:   []heys.Block{alpha},
+		Active:        []int{},
+		Probabilities: []float64{},
+		Probability:   1.0,
+	}
+}
+
+// extend returns copy of trail with one more round to output difference beta
+// with probability p
+func (t Trail) extend(spn *heys.SPN, beta heys.Block, p float64) Trail {
+	last, active := t.Differences[len(t.Differences)-1], 0
+	for i := 0; i < spn.Count(); i++ {
+		if spn.Nibble(int(last), i) != 0 {
+			active++
+		}
+	}
+	return Trail{
+		Differences:   append(append(make([]heys.Block, 0, len(t.Differences)+1), t.Differences...), beta),
+		Active:        append(append(make([]int, 0, len(t.Active)+1), t.Active...), active),
+		Probabilities: append(append(make([]float64, 0, len(t.Probabilities)+1), t.Probabilities...), p),
+		Probability:   t.Probability * p,
+	}
+}
+
+// accepts reports whether trail of probability p is among k the most probable
+// trails sorted in descending order
+func accepts(trails []Trail, p float64, k int) bool {
+	return len(trails) < k || trails[len(trails)-1].Probability < p
+}
+
+// insert adds trail to k the most probable trails sorted in descending order
+func insert(trails []Trail, trail Trail, k int) []Trail {
+	if !accepts(trails, trail.Probability, k) {
+		return trails
+	}
+	i := len(trails)
+	if i == k {
+		i--
+	} else {
+		trails = append(trails, Trail{})
+	}
+	for ; i > 0 && trails[i-1].Probability < trail.Probability; i-- {
+		trails[i] = trails[i-1]
+	}
+	trails[i] = trail
+	return trails
+}
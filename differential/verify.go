@@ -0,0 +1,125 @@
+package differential
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/mariiatuzovska/cryptanalysis/progress"
+	"github.com/mariiatuzovska/cryptanalysis/stats"
+)
+
+type (
+	// VerifyOptions are parameters of Verify, zero fields take default values
+	VerifyOptions struct {
+		// Cipher which S-box and SPN are used with random keys,
+		// heys.DefaultCipher() if nil
+		Cipher *heys.Cipher
+		// Rounds is count of rounds of differential, rounds of cipher minus
+		// the last one if zero
+		Rounds int
+		// Keys is count of random keys, at least 2, stats.Keys if zero
+		Keys int
+		// Texts is count of random plaintext pairs of every key, all pairs if
+		// zero
+		Texts int
+		// Rand chooses keys and plaintexts, seeded by math/rand package if nil
+		Rand *rand.Rand
+		// Progress receives count of keys done if not nil
+		Progress progress.Func
+	}
+	// Verification is empirical probability of differential averaged over
+	// random keys
+	Verification struct {
+		// Probability is mean of probabilities of keys
+		Probability float64 `json:"probability"`
+		// Low and High are bounds of 95% confidence interval of Probability
+		Low  float64 `json:"low"`
+		High float64 `json:"high"`
+		// Min and Max are the least and the greatest probabilities of keys
+		Min  float64 `json:"min"`
+		Max  float64 `json:"max"`
+		Keys int     `json:"keys"`
+		// Pairs is count of plaintext pairs of every key
+		Pairs int `json:"pairs"`
+	}
+)
+
+// withDefaults returns copy of options with default values of zero fields
+func (opts VerifyOptions) withDefaults() (VerifyOptions, error) {
+	if opts.Cipher == nil {
+		opts.Cipher = heys.DefaultCipher()
+	}
+	if opts.Rounds == 0 {
+		opts.Rounds = opts.Cipher.Rounds() - 1
+	}
+	if opts.Keys == 0 {
+		opts.Keys = stats.Keys
+	}
+	if opts.Rand == nil {
+		opts.Rand = rand.New(rand.NewSource(rand.Int63()))
+	}
+	if opts.Rounds < 1 || opts.Rounds > opts.Cipher.Rounds() {
+		return opts, fmt.Errorf("differential: invalid count of rounds %d of %d-round cipher", opts.Rounds, opts.Cipher.Rounds())
+	}
+	if opts.Keys < 2 {
+		return opts, fmt.Errorf("differential: invalid count of keys %d, confidence interval needs at least 2", opts.Keys)
+	}
+	if opts.Texts < 0 {
+		return opts, fmt.Errorf("differential: invalid count of texts %d", opts.Texts)
+	}
+	return opts, nil
+}
+
+// Verify measures probability of differential alpha, beta over opts.Rounds
+// rounds of cipher with random keys: for every key it counts pairs of
+// plaintexts x and x^alpha which outputs of heys.Cipher.EncryptRounds differ
+// by beta
+func Verify(ctx context.Context, alpha, beta heys.Block, opts VerifyOptions) (Verification, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return Verification{}, err
+	}
+	size, spn := opts.Cipher.Size(), opts.Cipher.SPN()
+	if alpha == 0 || beta == 0 || int(alpha) >= size || int(beta) >= size {
+		return Verification{}, fmt.Errorf("differential: invalid differential 0x%04x : 0x%04x of %d-bit block", alpha, beta, spn.BlockSize())
+	}
+	pairs := opts.Texts
+	if pairs == 0 {
+		pairs = size / 2
+	}
+	v := Verification{Min: 1.0, Keys: opts.Keys, Pairs: pairs}
+	tracker := progress.NewTracker(opts.Progress, "verify", opts.Keys)
+	probabilities := make([]float64, opts.Keys)
+	for k := range probabilities {
+		if ctx.Err() != nil {
+			return Verification{}, ctx.Err()
+		}
+		cipher, err := heys.NewSPNCipher(spn, heys.RandomSPNKeys(opts.Rand, spn.BlockSize(), spn.Rounds()), opts.Cipher.SBox())
+		if err != nil {
+			return Verification{}, err
+		}
+		count := 0
+		if opts.Texts == 0 {
+			for i := 0; i < size; i++ {
+				if x := heys.Block(i); x < x^alpha && cipher.EncryptRounds(x, opts.Rounds)^cipher.EncryptRounds(x^alpha, opts.Rounds) == beta {
+					count++
+				}
+			}
+		} else {
+			for i := 0; i < opts.Texts; i++ {
+				x := heys.Block(opts.Rand.Intn(size))
+				if cipher.EncryptRounds(x, opts.Rounds)^cipher.EncryptRounds(x^alpha, opts.Rounds) == beta {
+					count++
+				}
+			}
+		}
+		probabilities[k] = float64(count) / float64(pairs)
+		v.Min, v.Max = math.Min(v.Min, probabilities[k]), math.Max(v.Max, probabilities[k])
+		tracker.Add(0, 0, 1)
+	}
+	v.Probability, v.Low, v.High = stats.MeanInterval(probabilities)
+	return v, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mariiatuzovska/cryptanalysis/diffusion"
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/urfave/cli"
+)
+
+func main() {
+
+	app := cli.NewApp()
+	app.Name = "diffusion"
+	app.Usage = "diffusion analysis of Heys cipher linear layer command line client"
+	app.Description = "diffusion analysis of Heys cipher linear layer"
+	app.Version = "0.0.1"
+	app.Copyright = "2020, mariiatuzovska"
+	app.Authors = []cli.Author{cli.Author{Name: "Tuzovska Mariia"}}
+	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:  "spec",
+			Usage: "JSON cipher specification with S-box, permutation and rounds, Heys cipher if empty",
+		},
+		&cli.StringFlag{
+			Name:  "permutation",
+			Usage: "comma separated bit permutation, bit i moves to bit permutation[i], overrides --spec",
+		},
+		&cli.StringFlag{
+			Name:  "layer",
+			Usage: "comma separated hex columns of linear layer, column i is image of bit i, overrides --permutation",
+		},
+	}
+	app.Commands = []cli.Command{
+		{
+			Name:  "report",
+			Usage: "shows branch numbers, min active S-boxes and full diffusion rounds of linear layer",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:  "rounds",
+					Usage: "count of rounds for min active S-boxes, rounds of --spec if 0",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				spec, layer, err := loadLayer(c)
+				if err != nil {
+					return err
+				}
+				rounds := c.Int("rounds")
+				if rounds == 0 {
+					rounds = spec.Rounds
+				}
+				fmt.Println(fmt.Sprintf("layer columns              %x", layer.Columns()))
+				fmt.Println(fmt.Sprintf("differential branch number %d", layer.DifferentialBranchNumber()))
+				fmt.Println(fmt.Sprintf("linear branch number       %d", layer.LinearBranchNumber()))
+				fmt.Println(fmt.Sprintf("full diffusion rounds      %d (complete S-box %d)",
+					layer.FullDiffusionRounds(spec.SBox), layer.FullDiffusionRounds(nil)))
+				differential, linear := layer.MinActiveSBoxes(rounds), layer.MinLinearActiveSBoxes(rounds)
+				fmt.Println("rounds   min active S-boxes of differential trails   of linear trails")
+				for i := range differential {
+					fmt.Println(fmt.Sprintf("%6d   %38d   %16d", i+1, differential[i], linear[i]))
+				}
+				return nil
+			},
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func loadLayer(c *cli.Context) (*heys.Spec, *diffusion.Layer, error) {
+	spec := heys.DefaultSpec()
+	if c.GlobalString("spec") != "" {
+		var err error
+		if spec, err = heys.LoadSpec(c.GlobalString("spec")); err != nil {
+			return nil, nil, err
+		}
+	}
+	if c.GlobalString("permutation") != "" {
+		permutation, err := parseInts(c.GlobalString("permutation"), 10)
+		if err != nil {
+			return nil, nil, err
+		}
+		spec.Permutation = permutation
+	}
+	spn, err := spec.SPN()
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.GlobalString("layer") != "" {
+		columns, err := parseInts(c.GlobalString("layer"), 16)
+		if err != nil {
+			return nil, nil, err
+		}
+		layer, err := diffusion.NewLayer(spn, columns)
+		return spec, layer, err
+	}
+	layer, err := diffusion.PermutationLayer(spn)
+	return spec, layer, err
+}
+
+func parseInts(s string, base int) ([]int, error) {
+	fields := strings.Split(s, ",")
+	values := make([]int, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(field), "0x"), base, 32)
+		if err != nil {
+			return nil, fmt.Errorf("diffusion: %v", err)
+		}
+		values[i] = int(v)
+	}
+	return values, nil
+}
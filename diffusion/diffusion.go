@@ -0,0 +1,292 @@
+package diffusion
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+)
+
+// Layer is an invertible linear layer of SPN over GF(2), column i is image of
+// bit i of a block, bit permutation is a layer with one-bit columns
+type Layer struct {
+	spn     *heys.SPN
+	columns []int
+	// layer as lookup table by S-box position and value
+	table [][]int
+}
+
+// NewLayer returns linear layer of spn blocks with columns
+func NewLayer(spn *heys.SPN, columns []int) (*Layer, error) {
+	if len(columns) != spn.BlockSize() {
+		return nil, fmt.Errorf("diffusion: layer of %d-bit block must have %d columns, got %d", spn.BlockSize(), spn.BlockSize(), len(columns))
+	}
+	l := &Layer{spn: spn, columns: make([]int, len(columns))}
+	for i, c := range columns {
+		if c < 0 || c >= 1<<uint(spn.BlockSize()) {
+			return nil, fmt.Errorf("diffusion: column %d is out of %d-bit range", i, spn.BlockSize())
+		}
+		l.columns[i] = c
+	}
+	if _, err := l.inverseColumns(); err != nil {
+		return nil, err
+	}
+	l.table = make([][]int, spn.Count())
+	for i := range l.table {
+		l.table[i] = make([]int, 1<<uint(spn.Width()))
+		for v := range l.table[i] {
+			for b := 0; b < spn.Width(); b++ {
+				if (v>>uint(b))&1 == 1 {
+					l.table[i][v] ^= l.columns[i*spn.Width()+b]
+				}
+			}
+		}
+	}
+	return l, nil
+}
+
+// PermutationLayer returns layer of bit permutation of spn
+func PermutationLayer(spn *heys.SPN) (*Layer, error) {
+	columns := make([]int, spn.BlockSize())
+	for i, j := range spn.Permutation() {
+		columns[i] = 1 << uint(j)
+	}
+	return NewLayer(spn, columns)
+}
+
+// SPN returns SPN of layer
+func (l *Layer) SPN() *heys.SPN {
+	return l.spn
+}
+
+// Columns returns copy of columns of layer
+func (l *Layer) Columns() []int {
+	columns := make([]int, len(l.columns))
+	copy(columns, l.columns)
+	return columns
+}
+
+// Apply returns image of block
+func (l *Layer) Apply(block int) int {
+	mask := 1<<uint(l.spn.Width()) - 1
+	result := 0
+	for i := range l.table {
+		result ^= l.table[i][(block>>uint(i*l.spn.Width()))&mask]
+	}
+	return result
+}
+
+// Inverse returns inverse layer
+func (l *Layer) Inverse() *Layer {
+	columns, _ := l.inverseColumns()
+	inverse, _ := NewLayer(l.spn, columns)
+	return inverse
+}
+
+// Transpose returns transposed layer, masks of linear approximations go
+// backwards through transposed layer
+func (l *Layer) Transpose() *Layer {
+	columns := make([]int, len(l.columns))
+	for i, c := range l.columns {
+		for j := range columns {
+			if (c>>uint(j))&1 == 1 {
+				columns[j] |= 1 << uint(i)
+			}
+		}
+	}
+	transpose, _ := NewLayer(l.spn, columns)
+	return transpose
+}
+
+// DifferentialBranchNumber returns min count of active S-boxes of nonzero
+// input difference and its output difference
+func (l *Layer) DifferentialBranchNumber() int {
+	return l.branchNumber()
+}
+
+// LinearBranchNumber returns min count of active S-boxes of nonzero output
+// mask and its input mask
+func (l *Layer) LinearBranchNumber() int {
+	return l.Transpose().branchNumber()
+}
+
+// MinActiveSBoxes returns min count of active S-boxes of differential trails
+// over 1, ..., rounds rounds, S-box is assumed to map any nonzero difference
+// to any nonzero difference
+func (l *Layer) MinActiveSBoxes(rounds int) []int {
+	return l.minActive(rounds)
+}
+
+// MinLinearActiveSBoxes returns min count of active S-boxes of linear trails
+// over 1, ..., rounds rounds, S-box is assumed to map any nonzero mask to any
+// nonzero mask
+func (l *Layer) MinLinearActiveSBoxes(rounds int) []int {
+	return l.Inverse().Transpose().minActive(rounds)
+}
+
+// FullDiffusionRounds returns min count of rounds after that every bit of a
+// block depends on every bit of plaintext, dependencies of S-box bits are
+// taken from sBox or assumed complete if sBox is nil, -1 if full diffusion is
+// not reached in 64 rounds
+func (l *Layer) FullDiffusionRounds(sBox []int) int {
+	n, w := l.spn.BlockSize(), l.spn.Width()
+	if sBox != nil && len(sBox) != 1<<uint(w) {
+		return -1
+	}
+	// sDependency[k] is mask of input bits of S-box that output bit k depends on
+	sDependency := make([]int, w)
+	for k := range sDependency {
+		for j := 0; j < w; j++ {
+			if sBox == nil {
+				sDependency[k] |= 1 << uint(j)
+				continue
+			}
+			for x := range sBox {
+				if (sBox[x]^sBox[x^1<<uint(j)])>>uint(k)&1 == 1 {
+					sDependency[k] |= 1 << uint(j)
+					break
+				}
+			}
+		}
+	}
+	full := 1<<uint(n) - 1
+	dependency := make([]int, n)
+	for i := range dependency {
+		dependency[i] = 1 << uint(i)
+	}
+	for round := 1; round <= 64; round++ {
+		substituted := make([]int, n)
+		for k := range substituted {
+			box := k / w * w
+			for j := 0; j < w; j++ {
+				if (sDependency[k%w]>>uint(j))&1 == 1 {
+					substituted[k] |= dependency[box+j]
+				}
+			}
+		}
+		for k := range dependency {
+			dependency[k] = 0
+		}
+		for i, c := range l.columns {
+			for k := 0; k < n; k++ {
+				if (c>>uint(k))&1 == 1 {
+					dependency[k] |= substituted[i]
+				}
+			}
+		}
+		complete := true
+		for _, d := range dependency {
+			complete = complete && d == full
+		}
+		if complete {
+			return round
+		}
+	}
+	return -1
+}
+
+// Active returns count of nonzero S-boxes of a block
+func (l *Layer) Active(block int) int {
+	active := 0
+	for i := 0; i < l.spn.Count(); i++ {
+		if l.spn.Nibble(block, i) != 0 {
+			active++
+		}
+	}
+	return active
+}
+
+// pattern returns mask of nonzero S-boxes of a block
+func (l *Layer) pattern(block int) int {
+	pattern := 0
+	for i := 0; i < l.spn.Count(); i++ {
+		if l.spn.Nibble(block, i) != 0 {
+			pattern |= 1 << uint(i)
+		}
+	}
+	return pattern
+}
+
+func (l *Layer) branchNumber() int {
+	min := -1
+	for x := 1; x < 1<<uint(l.spn.BlockSize()); x++ {
+		if b := l.Active(x) + l.Active(l.Apply(x)); min == -1 || b < min {
+			min = b
+		}
+	}
+	return min
+}
+
+// minActive finds min active S-boxes by dynamic programming over S-box input
+// blocks of rounds, S-box output block of a round may be any block with the
+// same pattern of nonzero S-boxes as its input
+func (l *Layer) minActive(rounds int) []int {
+	size, none := 1<<uint(l.spn.BlockSize()), int(^uint(0)>>1)
+	cost := make([]int, size)
+	for x := range cost {
+		cost[x] = l.Active(x)
+	}
+	cost[0] = none
+	result := make([]int, 0, rounds)
+	for round := 1; round <= rounds; round++ {
+		best := make([]int, 1<<uint(l.spn.Count()))
+		for p := range best {
+			best[p] = none
+		}
+		for x := 1; x < size; x++ {
+			if p := l.pattern(x); cost[x] < best[p] {
+				best[p] = cost[x]
+			}
+		}
+		min := none
+		for _, c := range best {
+			if c < min {
+				min = c
+			}
+		}
+		result = append(result, min)
+		if round == rounds {
+			break
+		}
+		next := make([]int, size)
+		for x := range next {
+			next[x] = none
+		}
+		for y := 1; y < size; y++ {
+			if c := best[l.pattern(y)]; c != none {
+				if x := l.Apply(y); c+l.Active(x) < next[x] {
+					next[x] = c + l.Active(x)
+				}
+			}
+		}
+		cost = next
+	}
+	return result
+}
+
+// inverseColumns returns columns of inverse layer by Gauss-Jordan elimination
+func (l *Layer) inverseColumns() ([]int, error) {
+	n := len(l.columns)
+	images, preimages := make([]int, n), make([]int, n)
+	for i, c := range l.columns {
+		images[i], preimages[i] = c, 1<<uint(i)
+	}
+	for p := 0; p < n; p++ {
+		k := p
+		for k < n && (images[k]>>uint(p))&1 == 0 {
+			k++
+		}
+		if k == n {
+			return nil, errors.New("diffusion: linear layer is not invertible")
+		}
+		images[p], images[k] = images[k], images[p]
+		preimages[p], preimages[k] = preimages[k], preimages[p]
+		for i := range images {
+			if i != p && (images[i]>>uint(p))&1 == 1 {
+				images[i] ^= images[p]
+				preimages[i] ^= preimages[p]
+			}
+		}
+	}
+	return preimages, nil
+}
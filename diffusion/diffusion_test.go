@@ -0,0 +1,79 @@
+package diffusion
+
+import (
+	"testing"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+)
+
+func TestHeysPermutation(t *testing.T) {
+	spn, err := heys.HeysSPN(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := PermutationLayer(spn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := l.DifferentialBranchNumber(); b != 2 {
+		t.Errorf("differential branch number is %d, want 2", b)
+	}
+	if b := l.LinearBranchNumber(); b != 2 {
+		t.Errorf("linear branch number is %d, want 2", b)
+	}
+	// one bit goes through one S-box of every round
+	for _, active := range [][]int{l.MinActiveSBoxes(5), l.MinLinearActiveSBoxes(5)} {
+		for r, a := range active {
+			if a != r+1 {
+				t.Fatalf("min active S-boxes are %v, want 1, ..., 5", active)
+			}
+		}
+	}
+	if r := l.FullDiffusionRounds(heys.SBlocks); r != 2 {
+		t.Errorf("full diffusion rounds are %d, want 2", r)
+	}
+	for x := 0; x < 0x10000; x++ {
+		if y := l.Apply(x); y != spn.Permute(x) {
+			t.Fatalf("layer moves 0x%04x to 0x%04x, permutation to 0x%04x", x, y, spn.Permute(x))
+		}
+	}
+}
+
+func TestMixingLayer(t *testing.T) {
+	spn, err := heys.HeysSPN(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every nibble is XOR of the other three nibbles
+	columns := make([]int, spn.BlockSize())
+	for i := range columns {
+		for k := 0; k < spn.Count(); k++ {
+			if k != i/spn.Width() {
+				columns[i] |= 1 << uint(k*spn.Width()+i%spn.Width())
+			}
+		}
+	}
+	l, err := NewLayer(spn, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := l.DifferentialBranchNumber(); b != 4 {
+		t.Errorf("differential branch number is %d, want 4", b)
+	}
+	if b := l.LinearBranchNumber(); b != 4 {
+		t.Errorf("linear branch number is %d, want 4", b)
+	}
+	if active := l.MinActiveSBoxes(2); active[0] != 1 || active[1] != 4 {
+		t.Errorf("min active S-boxes are %v, want [1 4]", active)
+	}
+	inverse := l.Inverse()
+	for x := 0; x < 0x10000; x++ {
+		if y := inverse.Apply(l.Apply(x)); y != x {
+			t.Fatalf("inverse layer maps 0x%04x to 0x%04x", x, y)
+		}
+	}
+	columns[0] = columns[1]
+	if _, err := NewLayer(spn, columns); err == nil {
+		t.Error("singular layer is accepted")
+	}
+}
@@ -0,0 +1,49 @@
+package heys
+
+import (
+	"crypto/cipher"
+	"fmt"
+)
+
+// BlockSize is the Heys block size in bytes
+const BlockSize = 2
+
+type block struct {
+	c *Cipher
+}
+
+// NewBlock wraps Heys cipher with 16-bit block as crypto/cipher.Block, blocks
+// are little-endian like in ConvertDataToBlocks, ciphers of narrower blocks
+// are rejected as bytes may hold blocks out of their range
+func NewBlock(c *Cipher) (cipher.Block, error) {
+	if c.SPN().BlockSize() != 8*BlockSize {
+		return nil, fmt.Errorf("heys: crypto/cipher block needs %d-bit block, got %d-bit", 8*BlockSize, c.SPN().BlockSize())
+	}
+	return &block{c}, nil
+}
+
+func (b *block) BlockSize() int {
+	return BlockSize
+}
+
+func (b *block) Encrypt(dst, src []byte) {
+	if len(src) < BlockSize {
+		panic("heys: input not full block")
+	}
+	if len(dst) < BlockSize {
+		panic("heys: output not full block")
+	}
+	y := b.c.Encrypt(Block(src[0]) | Block(src[1])<<8)
+	dst[0], dst[1] = byte(y), byte(y>>8)
+}
+
+func (b *block) Decrypt(dst, src []byte) {
+	if len(src) < BlockSize {
+		panic("heys: input not full block")
+	}
+	if len(dst) < BlockSize {
+		panic("heys: output not full block")
+	}
+	x := b.c.Decrypt(Block(src[0]) | Block(src[1])<<8)
+	dst[0], dst[1] = byte(x), byte(x>>8)
+}
@@ -0,0 +1,216 @@
+package heys
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+)
+
+// readPlain returns plaintext of the community attack, all blocks in order
+func readPlain(t *testing.T) []byte {
+	t.Helper()
+	data, err := ioutil.ReadFile("../differential/cmd/community/plain.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// defaultBlock returns crypto/cipher block of DefaultCipher
+func defaultBlock(t *testing.T) cipher.Block {
+	t.Helper()
+	b, err := NewBlock(DefaultCipher())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// convert converts data to blocks
+func convert(t *testing.T, data []byte) []Block {
+	t.Helper()
+	blocks, err := ConvertDataToBlocks(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blocks
+}
+
+func TestBlockEncrypt(t *testing.T) {
+	known := map[Block]Block{
+		0x0000: 0x6659,
+		0x0001: 0x8486,
+		0x1234: 0x98c8,
+		0xabcd: 0xe640,
+		0xffff: 0xa541,
+	}
+	data := readPlain(t)
+	blocks := convert(t, data)
+	if len(blocks) != 0x10000 {
+		t.Fatalf("plain.txt has %d blocks, want 0x10000", len(blocks))
+	}
+	b := defaultBlock(t)
+	encrypted := make([]byte, len(data))
+	for i := 0; i < len(data); i += BlockSize {
+		b.Encrypt(encrypted[i:], data[i:])
+	}
+	for i, y := range convert(t, encrypted) {
+		x := blocks[i]
+		if want := EncryptWithKey(x); y != want {
+			t.Fatalf("block 0x%04x is encrypted to 0x%04x, EncryptWithKey gives 0x%04x", x, y, want)
+		}
+		if want, ok := known[x]; ok && y != want {
+			t.Fatalf("block 0x%04x is encrypted to 0x%04x, want 0x%04x", x, y, want)
+		}
+	}
+	decrypted := make([]byte, len(data))
+	for i := 0; i < len(data); i += BlockSize {
+		b.Decrypt(decrypted[i:], encrypted[i:])
+	}
+	if !bytes.Equal(decrypted, data) {
+		t.Fatal("ecb does not decrypt plain.txt back")
+	}
+}
+
+func TestBlockModes(t *testing.T) {
+	b, iv, data := defaultBlock(t), []byte{0x3a, 0x91}, readPlain(t)
+	modes := []struct {
+		name    string
+		encrypt func(dst, src []byte)
+		decrypt func(dst, src []byte)
+	}{
+		{"cbc", cipher.NewCBCEncrypter(b, iv).CryptBlocks, cipher.NewCBCDecrypter(b, iv).CryptBlocks},
+		{"ctr", cipher.NewCTR(b, iv).XORKeyStream, cipher.NewCTR(b, iv).XORKeyStream},
+		{"ofb", cipher.NewOFB(b, iv).XORKeyStream, cipher.NewOFB(b, iv).XORKeyStream},
+		{"cfb", cipher.NewCFBEncrypter(b, iv).XORKeyStream, cipher.NewCFBDecrypter(b, iv).XORKeyStream},
+	}
+	for _, mode := range modes {
+		encrypted, decrypted := make([]byte, len(data)), make([]byte, len(data))
+		mode.encrypt(encrypted, data)
+		if bytes.Equal(encrypted, data) {
+			t.Errorf("%s mode does not change data", mode.name)
+		}
+		mode.decrypt(decrypted, encrypted)
+		if !bytes.Equal(decrypted, data) {
+			t.Errorf("%s mode does not decrypt data back", mode.name)
+		}
+	}
+}
+
+func TestBlockRejectsBadInput(t *testing.T) {
+	if blocks, err := ConvertDataToBlocks([]byte{1, 2, 3}); err == nil {
+		t.Errorf("odd data is converted to blocks %04x", blocks)
+	}
+	spn, err := NewSPN(4, 3, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(1))
+	c, err := NewSPNCipher(spn, RandomSPNKeys(r, spn.BlockSize(), spn.Rounds()), SBlocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBlock(c); err == nil {
+		t.Error("12-bit cipher is wrapped as crypto/cipher block")
+	}
+	if _, err := EncryptData(c, "ecb", nil, []byte{0xff, 0xff}); err == nil {
+		t.Error("12-bit cipher encrypts 16-bit data")
+	}
+}
+
+// encryptStream encrypts data with writer of mode in chunks of random sizes
+func encryptStream(t *testing.T, r *rand.Rand, c *Cipher, mode string, iv, data []byte) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	w, err := NewEncryptWriter(buf, c, mode, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p := data; len(p) > 0; {
+		n := 1 + r.Intn(len(p))
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestStream(t *testing.T) {
+	c, iv, r := DefaultCipher(), []byte{0x3a, 0x91}, rand.New(rand.NewSource(1))
+	readers := []struct {
+		name string
+		wrap func(io.Reader) io.Reader
+	}{
+		{"whole", func(r io.Reader) io.Reader { return r }},
+		{"one byte", iotest.OneByteReader},
+		{"half", iotest.HalfReader},
+	}
+	lengths := []int{chunkSize - 1, chunkSize, chunkSize + 3}
+	for n := 0; n <= 3*BlockSize+1; n++ {
+		lengths = append(lengths, n)
+	}
+	for _, mode := range Modes {
+		for _, n := range lengths {
+			data := make([]byte, n)
+			r.Read(data)
+			encrypted := encryptStream(t, r, c, mode, iv, data)
+			if want, err := EncryptData(c, mode, iv, data); err != nil || !bytes.Equal(encrypted, want) {
+				t.Fatalf("%s: writer of %d bytes differs from EncryptData, %v", mode, n, err)
+			}
+			for _, reader := range readers {
+				dr, err := NewDecryptReader(reader.wrap(bytes.NewReader(encrypted)), c, mode, iv)
+				if err != nil {
+					t.Fatal(err)
+				}
+				decrypted, err := ioutil.ReadAll(dr)
+				if err != nil {
+					t.Fatalf("%s: %s reader of %d bytes: %v", mode, reader.name, n, err)
+				}
+				if !bytes.Equal(decrypted, data) {
+					t.Fatalf("%s: %s reader does not decrypt %d bytes back", mode, reader.name, n)
+				}
+			}
+		}
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	c, iv, b := DefaultCipher(), []byte{0x3a, 0x91}, defaultBlock(t)
+	// last block which plaintext has zero pad byte
+	invalid := []byte{0x01, 0x00}
+	for _, mode := range []string{"ecb", "cbc"} {
+		for _, n := range []int{0, 1, 2, 5} {
+			data := bytes.Repeat([]byte{0x5c}, n)
+			encrypted, err := EncryptData(c, mode, iv, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := DecryptData(c, mode, iv, encrypted[:len(encrypted)-1]); err == nil {
+				t.Errorf("%s: truncated ciphertext of %d bytes is decrypted", mode, n)
+			}
+			tampered := append([]byte(nil), encrypted...)
+			last, previous := tampered[len(tampered)-BlockSize:], make([]byte, BlockSize)
+			if mode == "cbc" {
+				previous = iv
+				if len(tampered) > BlockSize {
+					previous = tampered[len(tampered)-2*BlockSize:]
+				}
+			}
+			for i := range last {
+				last[i] = invalid[i] ^ previous[i]
+			}
+			b.Encrypt(last, last)
+			if _, err := DecryptData(c, mode, iv, tampered); err == nil {
+				t.Errorf("%s: ciphertext of %d bytes with tampered last block is decrypted", mode, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package heys
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Cipher is a keyed instance of Heys cipher or of other SPN with block up to
+// 16 bits, its methods take blocks less than Size() like the lookup tables,
+// blocks from data are checked by ConvertDataToBlocks, NewBlock and options
+// of searches and attacks
+type Cipher struct {
+	spn  *SPN
+	keys []Key
+	sBox []int
+	iBox []int
+	// one round without key and its inverse for all blocks
+	round   []Block
+	inverse []Block
+}
+
+// NewCipher returns Heys cipher with rounds+1 round keys and 4-bit S-box,
+// inverse S-box is derived from sBox
+func NewCipher(keys []Key, sBox []int, rounds int) (*Cipher, error) {
+	spn, err := HeysSPN(rounds)
+	if err != nil {
+		return nil, err
+	}
+	return NewSPNCipher(spn, keys, sBox)
+}
+
+// NewSPNCipher returns cipher of spn with rounds+1 round keys and S-box of spn
+// width, inverse S-box is derived from sBox
+func NewSPNCipher(spn *SPN, keys []Key, sBox []int) (*Cipher, error) {
+	if len(keys) != spn.Rounds()+1 {
+		return nil, fmt.Errorf("heys: %d rounds need %d round keys, got %d", spn.Rounds(), spn.Rounds()+1, len(keys))
+	}
+	for i, key := range keys {
+		if int(key) >= 1<<uint(spn.BlockSize()) {
+			return nil, fmt.Errorf("heys: round key #%d 0x%x is out of %d-bit range", i, key, spn.BlockSize())
+		}
+	}
+	if len(sBox) != 1<<uint(spn.Width()) {
+		return nil, fmt.Errorf("heys: %d-bit S-box must have %d entries, got %d", spn.Width(), 1<<uint(spn.Width()), len(sBox))
+	}
+	iBox, err := InverseSBox(sBox)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cipher{
+		spn:  spn,
+		keys: make([]Key, len(keys)),
+		sBox: make([]int, len(sBox)),
+		iBox: iBox,
+	}
+	copy(c.keys, keys)
+	copy(c.sBox, sBox)
+	c.round, c.inverse = make([]Block, c.Size()), make([]Block, c.Size())
+	for x := 0; x < c.Size(); x++ {
+		c.round[x] = Block(spn.Permute(spn.Substitute(x, sBox)))
+		c.inverse[x] = Block(spn.Substitute(spn.InversePermute(x), iBox))
+	}
+	return c, nil
+}
+
+// DefaultCipher returns Heys cipher with Defaultkey and SBlocks
+func DefaultCipher() *Cipher {
+	c, err := NewCipher(Defaultkey, SBlocks, len(Defaultkey)-1)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// RandomSPNKeys returns rounds+1 random round keys for blockSize-bit blocks
+func RandomSPNKeys(r *rand.Rand, blockSize, rounds int) []Key {
+	keys := make([]Key, rounds+1)
+	for i := range keys {
+		keys[i] = Key(r.Intn(1 << uint(blockSize)))
+	}
+	return keys
+}
+
+// InverseSBox returns inverse of S-box or error if S-box is not bijective,
+// count of S-box entries must be a power of two
+func InverseSBox(sBox []int) ([]int, error) {
+	n := len(sBox)
+	if n < 2 || n > 0x100 || n&(n-1) != 0 {
+		return nil, fmt.Errorf("heys: count of S-box entries %d is not a power of two up to 256", n)
+	}
+	iBox := make([]int, n)
+	for i := range iBox {
+		iBox[i] = -1
+	}
+	for x, y := range sBox {
+		if y < 0 || y >= n {
+			return nil, fmt.Errorf("heys: S-box value 0x%x at 0x%x is out of range", y, x)
+		}
+		if iBox[y] != -1 {
+			return nil, fmt.Errorf("heys: S-box is not bijective, value 0x%x repeats at 0x%x and 0x%x", y, iBox[y], x)
+		}
+		iBox[y] = x
+	}
+	return iBox, nil
+}
+
+// SPN returns description of substitution-permutation network
+func (c *Cipher) SPN() *SPN {
+	return c.spn
+}
+
+// Size returns count of all blocks
+func (c *Cipher) Size() int {
+	return 1 << uint(c.spn.BlockSize())
+}
+
+// Rounds returns count of rounds
+func (c *Cipher) Rounds() int {
+	return c.spn.Rounds()
+}
+
+// Keys returns copy of round keys
+func (c *Cipher) Keys() []Key {
+	keys := make([]Key, len(c.keys))
+	copy(keys, c.keys)
+	return keys
+}
+
+// SBox returns copy of S-box
+func (c *Cipher) SBox() []int {
+	sBox := make([]int, len(c.sBox))
+	copy(sBox, c.sBox)
+	return sBox
+}
+
+// Encrypt encrypts block with all rounds and round keys
+func (c *Cipher) Encrypt(block Block) Block {
+	rounds := len(c.keys) - 1
+	return c.EncryptRounds(block, rounds) ^ Block(c.keys[rounds])
+}
+
+// EncryptRounds encrypts block with the first rounds rounds and their keys
+// without the key that follows the last of them, differences and masks of
+// rounds rounds hold on it
+func (c *Cipher) EncryptRounds(block Block, rounds int) Block {
+	for i := 0; i < rounds; i++ {
+		block = c.round[block^Block(c.keys[i])]
+	}
+	return block
+}
+
+// Decrypt decrypts block with all rounds and round keys
+func (c *Cipher) Decrypt(block Block) Block {
+	rounds := len(c.keys) - 1
+	block = block ^ Block(c.keys[rounds])
+	for i := rounds - 1; i > -1; i-- {
+		block = c.inverse[block] ^ Block(c.keys[i])
+	}
+	return block
+}
+
+// EncryptRound makes one round without key: substitution and permutation
+func (c *Cipher) EncryptRound(block Block) Block {
+	return c.round[block]
+}
+
+// DecryptRound inverts EncryptRound
+func (c *Cipher) DecryptRound(block Block) Block {
+	return c.inverse[block]
+}
+
+// EncryptAll encrypts all blocks with all rounds and round keys
+func (c *Cipher) EncryptAll() []Block {
+	encrypted := make([]Block, c.Size())
+	for x := range encrypted {
+		encrypted[x] = c.Encrypt(Block(x))
+	}
+	return encrypted
+}
+
+// DecryptAll decrypts all blocks with all rounds and round keys
+func (c *Cipher) DecryptAll() []Block {
+	decrypted := make([]Block, c.Size())
+	for x := range decrypted {
+		decrypted[x] = c.Decrypt(Block(x))
+	}
+	return decrypted
+}
+
+// EncryptRoundAll makes one round without key for all blocks
+func (c *Cipher) EncryptRoundAll() []Block {
+	encrypted := make([]Block, c.Size())
+	copy(encrypted, c.round)
+	return encrypted
+}
+
+// DecryptRoundAll inverts one round without key for all blocks
+func (c *Cipher) DecryptRoundAll() []Block {
+	decrypted := make([]Block, c.Size())
+	copy(decrypted, c.inverse)
+	return decrypted
+}
@@ -0,0 +1,129 @@
+package heys
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// checkInverse checks that c.Decrypt inverts c.Encrypt for all blocks
+func checkInverse(t *testing.T, c *Cipher) {
+	t.Helper()
+	for i := 0; i < c.Size(); i++ {
+		x := Block(i)
+		y := c.Encrypt(x)
+		if z := c.Decrypt(y); z != x {
+			t.Fatalf("keys %04x: block 0x%04x is encrypted to 0x%04x and decrypted to 0x%04x", c.Keys(), x, y, z)
+		}
+	}
+}
+
+func TestDecryptInvertsEncrypt(t *testing.T) {
+	cipher := DefaultCipher()
+	for i := 0; i < 0x10000; i++ {
+		x := Block(i)
+		y := EncryptWithKey(x)
+		if z := DecryptWithKey(y); z != x {
+			t.Fatalf("default key: block 0x%04x is encrypted to 0x%04x and decrypted to 0x%04x", x, y, z)
+		}
+		if c := cipher.Encrypt(x); c != y {
+			t.Fatalf("default key: Cipher encrypts block 0x%04x to 0x%04x, EncryptWithKey to 0x%04x", x, c, y)
+		}
+	}
+	checkInverse(t, cipher)
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		c, err := NewCipher(RandomSPNKeys(r, 16, 6), SBlocks, 6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkInverse(t, c)
+	}
+}
+
+func TestTables(t *testing.T) {
+	c := DefaultCipher()
+	for i := 0; i < c.Size(); i++ {
+		x := Block(i)
+		if y := Encrypt(x); c.EncryptRound(x) != y {
+			t.Fatalf("round of 0x%04x is 0x%04x, table gives 0x%04x", x, y, c.EncryptRound(x))
+		}
+		if y := Decrypt(x); c.DecryptRound(x) != y {
+			t.Fatalf("inverse round of 0x%04x is 0x%04x, table gives 0x%04x", x, y, c.DecryptRound(x))
+		}
+	}
+}
+
+// sink keeps results of benchmarks from being optimized away
+var sink Block
+
+func BenchmarkEncrypt(b *testing.B) {
+	b.Run("nibbles", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sink ^= EncryptWithKey(Block(i))
+		}
+	})
+	b.Run("tables", func(b *testing.B) {
+		c := DefaultCipher()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			sink ^= c.Encrypt(Block(i))
+		}
+	})
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	b.Run("nibbles", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sink ^= DecryptWithKey(Block(i))
+		}
+	})
+	b.Run("tables", func(b *testing.B) {
+		c := DefaultCipher()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			sink ^= c.Decrypt(Block(i))
+		}
+	})
+}
+
+// BenchmarkEncryptAll encrypts all blocks, tables are built for every
+// operation as a new key needs a new cipher
+func BenchmarkEncryptAll(b *testing.B) {
+	spn, err := HeysSPN(len(Defaultkey) - 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("nibbles", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sink ^= EncryptAllWithKey()[i&0xffff]
+		}
+	})
+	b.Run("tables", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			c, err := NewSPNCipher(spn, Defaultkey, SBlocks)
+			if err != nil {
+				b.Fatal(err)
+			}
+			sink ^= c.EncryptAll()[i&0xffff]
+		}
+	})
+}
+
+func TestNarrowBlock(t *testing.T) {
+	spn, err := NewSPN(4, 3, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(1))
+	c, err := NewSPNCipher(spn, RandomSPNKeys(r, spn.BlockSize(), spn.Rounds()), SBlocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInverse(t, c)
+	for i := 0; i < c.Size(); i++ {
+		if y := c.Encrypt(Block(i)); int(y) >= c.Size() {
+			t.Fatalf("block 0x%03x is encrypted to 0x%04x out of 12 bits", i, y)
+		}
+	}
+}
@@ -1,70 +1,85 @@
 package heys
 
+import "fmt"
+
+type (
+	// Block is a block of Heys cipher
+	Block uint16
+	// Key is a round key of Heys cipher
+	Key uint16
+)
+
 var (
-	Defaultkey = []int{0x7a2b, 0xd01e, 0x1cc9, 0x467f, 0x0553, 0xc131, 0x31cc}
+	Defaultkey = []Key{0x7a2b, 0xd01e, 0x1cc9, 0x467f, 0x0553, 0xc131, 0x31cc}
 	SBlocks    = []int{0xF, 0x8, 0xE, 0x9, 0x7, 0x2, 0x0, 0xD, 0xC, 0x6, 0x1, 0x5, 0xB, 0x4, 0x3, 0xA}
 	IBlocks    = []int{0x6, 0xA, 0x5, 0xE, 0xD, 0xB, 0x9, 0x4, 0x1, 0x3, 0xF, 0xC, 0x8, 0x7, 0x2, 0x0}
+
+	defaultSPN, _ = HeysSPN(len(Defaultkey) - 1)
 )
 
-func EncryptAllWithKey() []int {
-	encrypted := make([]int, 0x10000)
-	for x := 0; x < 0x10000; x++ {
-		encrypted[x] = EncryptWithKey(x)
+func EncryptAllWithKey() []Block {
+	encrypted := make([]Block, 0x10000)
+	for x := range encrypted {
+		encrypted[x] = EncryptWithKey(Block(x))
 	}
 	return encrypted
 }
 
-func DecryptAllWithKey() []int {
-	decrypted := make([]int, 0x10000)
-	for x := 0; x < 0x10000; x++ {
-		decrypted[x] = DecryptWithKey(x)
+func DecryptAllWithKey() []Block {
+	decrypted := make([]Block, 0x10000)
+	for x := range decrypted {
+		decrypted[x] = DecryptWithKey(Block(x))
 	}
 	return decrypted
 }
 
-func EncryptWithKey(block int) int {
-	for i := 0; i < 6; i++ {
-		block = Permutation(Substitution(block^Defaultkey[i], SBlocks))
+func EncryptWithKey(block Block) Block {
+	rounds := len(Defaultkey) - 1
+	for i := 0; i < rounds; i++ {
+		block = Permutation(Substitution(block^Block(Defaultkey[i]), SBlocks))
 	}
-	return block ^ Defaultkey[6]
+	return block ^ Block(Defaultkey[rounds])
 }
 
-func DecryptWithKey(block int) int {
-	block = block ^ Defaultkey[6]
-	for i := 5; i > -1; i-- {
-		block = Substitution(Permutation(block), IBlocks)
+func DecryptWithKey(block Block) Block {
+	rounds := len(Defaultkey) - 1
+	block = block ^ Block(Defaultkey[rounds])
+	for i := rounds - 1; i > -1; i-- {
+		block = Substitution(Permutation(block), IBlocks) ^ Block(Defaultkey[i])
 	}
 	return block
 }
 
-func EncryptAll() []int {
-	encrypted := make([]int, 0x10000)
-	for x := 0; x < 0x10000; x++ {
-		encrypted[x] = Encrypt(x)
+func EncryptAll() []Block {
+	encrypted := make([]Block, 0x10000)
+	for x := range encrypted {
+		encrypted[x] = Encrypt(Block(x))
 	}
 	return encrypted
 }
 
-func DecryptAll() []int {
-	decrypted := make([]int, 0x10000)
-	for x := 0; x < 0x10000; x++ {
-		decrypted[x] = Decrypt(x)
+func DecryptAll() []Block {
+	decrypted := make([]Block, 0x10000)
+	for x := range decrypted {
+		decrypted[x] = Decrypt(Block(x))
 	}
 	return decrypted
 }
 
-func ConvertDataToBlocks(data []byte) []int {
+// ConvertDataToBlocks converts little-endian data to blocks, data of odd
+// length is rejected, Pad data to encrypt it
+func ConvertDataToBlocks(data []byte) ([]Block, error) {
 	if len(data)&1 != 0 {
-		data = append(data, 0)
+		return nil, fmt.Errorf("heys: block data length %d is odd", len(data))
 	}
-	blocks := make([]int, len(data)/2)
+	blocks := make([]Block, len(data)/2)
 	for i := 0; i < len(blocks); i++ {
-		blocks[i] = (int(data[i*2+1]) << 8) + int(data[i*2])
+		blocks[i] = Block(data[i*2+1])<<8 | Block(data[i*2])
 	}
-	return blocks
+	return blocks, nil
 }
 
-func ConvertBlocksToData(blocks []int) []byte {
+func ConvertBlocksToData(blocks []Block) []byte {
 	data := make([]byte, len(blocks)*2)
 	for i := 0; i < len(blocks); i++ {
 		data[i*2] = byte(blocks[i])
@@ -73,50 +88,31 @@ func ConvertBlocksToData(blocks []int) []byte {
 	return data
 }
 
-func Encrypt(block int) int {
+// ConvertDataToKeys converts little-endian data to round keys, data of odd
+// length is rejected
+func ConvertDataToKeys(data []byte) ([]Key, error) {
+	if len(data)&1 != 0 {
+		return nil, fmt.Errorf("heys: key data length %d is odd", len(data))
+	}
+	keys := make([]Key, len(data)/2)
+	for i := 0; i < len(keys); i++ {
+		keys[i] = Key(data[i*2+1])<<8 | Key(data[i*2])
+	}
+	return keys, nil
+}
+
+func Encrypt(block Block) Block {
 	return Permutation(Substitution(block, SBlocks))
 }
 
-func Decrypt(block int) int {
+func Decrypt(block Block) Block {
 	return Substitution(Permutation(block), IBlocks)
 }
 
-func Permutation(block int) int {
-	temp := block
-	block = 0
-	block |= (temp & 0x8421) << 0
-	block |= (temp & 0x0842) << 3
-	block |= (temp & 0x0084) << 6
-	block |= (temp & 0x0008) << 9
-	block |= (temp & 0x4210) >> 3
-	block |= (temp & 0x2100) >> 6
-	block |= (temp & 0x1000) >> 9
-	return block
+func Permutation(block Block) Block {
+	return Block(defaultSPN.Permute(int(block)))
 }
 
-func Substitution(block int, sBox []int) int {
-
-	b := block
-
-	nibble := (b >> 0) & 0xF
-	temp := (sBox[nibble] << 0)
-	b &= 0xFFF0
-	b |= temp
-
-	nibble = (b >> 4) & 0xF
-	temp = (sBox[nibble] << 4)
-	b &= 0xFF0F
-	b |= temp
-
-	nibble = (b >> 8) & 0xF
-	temp = (sBox[nibble] << 8)
-	b &= 0xF0FF
-	b |= temp
-
-	nibble = (b >> 12) & 0xF
-	temp = (sBox[nibble] << 12)
-	b &= 0x0FFF
-	b |= temp
-
-	return b
+func Substitution(block Block, sBox []int) Block {
+	return Block(defaultSPN.Substitute(int(block), sBox))
 }
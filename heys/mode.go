@@ -0,0 +1,121 @@
+package heys
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// Modes of operation supported by EncryptData and DecryptData
+var Modes = []string{"ecb", "cbc", "ctr", "ofb", "cfb"}
+
+// Pad appends PKCS#7 padding, there is at least one byte of padding
+func Pad(data []byte, blockSize int) []byte {
+	n := blockSize - len(data)%blockSize
+	padded := make([]byte, len(data), len(data)+n)
+	copy(padded, data)
+	for i := 0; i < n; i++ {
+		padded = append(padded, byte(n))
+	}
+	return padded
+}
+
+// Unpad strips PKCS#7 padding and returns error if padding is malformed
+func Unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, fmt.Errorf("heys: padded data length %d is not a positive multiple of %d", len(data), blockSize)
+	}
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize {
+		return nil, fmt.Errorf("heys: invalid padding length %d", n)
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, errors.New("heys: invalid padding bytes")
+		}
+	}
+	return data[:len(data)-n], nil
+}
+
+// EncryptData encrypts data with c in mode, ecb and cbc modes pad data
+func EncryptData(c *Cipher, mode string, iv, data []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w, err := NewEncryptWriter(buf, c, mode, iv)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecryptData decrypts data with c in mode, ecb and cbc modes strictly unpad data
+func DecryptData(c *Cipher, mode string, iv, data []byte) ([]byte, error) {
+	r, err := NewDecryptReader(bytes.NewReader(data), c, mode, iv)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func check(mode string, iv []byte) error {
+	if mode != "ecb" && len(iv) != BlockSize {
+		return fmt.Errorf("heys: %s mode needs %d-byte IV, got %d bytes", mode, BlockSize, len(iv))
+	}
+	return nil
+}
+
+func newBlockMode(b cipher.Block, mode string, iv []byte, encrypt bool) cipher.BlockMode {
+	switch {
+	case mode == "ecb":
+		return ecb{b, encrypt}
+	case mode == "cbc" && encrypt:
+		return cipher.NewCBCEncrypter(b, iv)
+	case mode == "cbc":
+		return cipher.NewCBCDecrypter(b, iv)
+	}
+	return nil
+}
+
+func newStream(b cipher.Block, mode string, iv []byte, encrypt bool) (cipher.Stream, error) {
+	switch mode {
+	case "ctr":
+		return cipher.NewCTR(b, iv), nil
+	case "ofb":
+		return cipher.NewOFB(b, iv), nil
+	case "cfb":
+		if encrypt {
+			return cipher.NewCFBEncrypter(b, iv), nil
+		}
+		return cipher.NewCFBDecrypter(b, iv), nil
+	}
+	return nil, fmt.Errorf("heys: unknown mode %q", mode)
+}
+
+type ecb struct {
+	b       cipher.Block
+	encrypt bool
+}
+
+func (m ecb) BlockSize() int {
+	return m.b.BlockSize()
+}
+
+func (m ecb) CryptBlocks(dst, src []byte) {
+	if len(src)%m.b.BlockSize() != 0 {
+		panic("heys: input not full blocks")
+	}
+	for i := 0; i < len(src); i += m.b.BlockSize() {
+		if m.encrypt {
+			m.b.Encrypt(dst[i:], src[i:])
+		} else {
+			m.b.Decrypt(dst[i:], src[i:])
+		}
+	}
+}
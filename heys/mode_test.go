@@ -0,0 +1,70 @@
+package heys
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPad(t *testing.T) {
+	for n := 0; n <= 3*BlockSize+1; n++ {
+		data := bytes.Repeat([]byte{0xa5}, n)
+		padded := Pad(data, BlockSize)
+		if len(padded)%BlockSize != 0 || len(padded) <= n || len(padded) > n+BlockSize {
+			t.Fatalf("%d bytes are padded to %d bytes", n, len(padded))
+		}
+		unpadded, err := Unpad(padded, BlockSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(unpadded, data) {
+			t.Fatalf("%d bytes are unpadded to %x", n, unpadded)
+		}
+	}
+}
+
+func TestUnpadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not multiple of block size", []byte{1, 2, 1}},
+		{"pad byte 0", []byte{1, 2, 3, 0}},
+		{"pad byte greater than block size", []byte{1, 2, 3, 3}},
+		{"inconsistent pad bytes", []byte{1, 2, 1, 2}},
+	}
+	for _, test := range tests {
+		if data, err := Unpad(test.data, BlockSize); err == nil {
+			t.Errorf("%s: %x is unpadded to %x", test.name, test.data, data)
+		}
+	}
+	if data, err := Unpad([]byte{1, 2, 3, 4, 4, 2, 3, 3}, 4); err == nil {
+		t.Errorf("inconsistent pad bytes of 4-byte blocks are unpadded to %x", data)
+	}
+}
+
+func TestEncryptData(t *testing.T) {
+	c, iv := DefaultCipher(), []byte{0x3a, 0x91}
+	for _, mode := range Modes {
+		for n := 0; n <= 3*BlockSize+1; n++ {
+			data := make([]byte, n)
+			for i := range data {
+				data[i] = byte(7*i + n)
+			}
+			encrypted, err := EncryptData(c, mode, iv, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (mode == "ecb" || mode == "cbc") && len(encrypted) != len(Pad(data, BlockSize)) {
+				t.Fatalf("%s: %d bytes are encrypted to %d bytes", mode, n, len(encrypted))
+			}
+			decrypted, err := DecryptData(c, mode, iv, encrypted)
+			if err != nil {
+				t.Fatalf("%s: %d bytes: %v", mode, n, err)
+			}
+			if !bytes.Equal(decrypted, data) {
+				t.Fatalf("%s: %x is decrypted to %x", mode, data, decrypted)
+			}
+		}
+	}
+}
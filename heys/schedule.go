@@ -0,0 +1,196 @@
+package heys
+
+import (
+	"errors"
+	"fmt"
+)
+
+// KeySchedule expands master key to round keys
+type KeySchedule interface {
+	// Expand returns spn.Rounds()+1 round keys from master key
+	Expand(spn *SPN, master []Key) ([]Key, error)
+}
+
+// KeyScheduleInverter is implemented by key schedules that can recover master
+// key from one round key, differential attack finds the last round key and
+// linear attack the first one
+type KeyScheduleInverter interface {
+	KeySchedule
+	// Master returns master key which round key #round is key, round is from 0
+	// to spn.Rounds()
+	Master(spn *SPN, round int, key Key) ([]Key, error)
+}
+
+type (
+	// IndependentSchedule uses master key as rounds+1 independent round keys
+	IndependentSchedule struct{}
+	// RotationSchedule rotates one-block master key left by Shift*i bits for
+	// round key i
+	RotationSchedule struct {
+		Shift int
+	}
+	// AESSchedule is a toy AES-like schedule over S-box sized words of
+	// one-block master key: the first word is mixed with S-box of the last
+	// word and round constant, other words are chained by XOR
+	AESSchedule struct {
+		SBox []int
+	}
+)
+
+// Schedules are names of key schedules for NewKeySchedule
+var Schedules = []string{"independent", "rotation", "aes"}
+
+// NewKeySchedule returns key schedule by name, shift is used by rotation
+// schedule and sBox by aes schedule
+func NewKeySchedule(name string, shift int, sBox []int) (KeySchedule, error) {
+	switch name {
+	case "", "independent":
+		return IndependentSchedule{}, nil
+	case "rotation":
+		return RotationSchedule{shift}, nil
+	case "aes":
+		if _, err := InverseSBox(sBox); err != nil {
+			return nil, err
+		}
+		return AESSchedule{sBox}, nil
+	}
+	return nil, fmt.Errorf("heys: unknown key schedule %q", name)
+}
+
+func (IndependentSchedule) Expand(spn *SPN, master []Key) ([]Key, error) {
+	if len(master) != spn.Rounds()+1 {
+		return nil, fmt.Errorf("heys: independent schedule needs %d keys, got %d", spn.Rounds()+1, len(master))
+	}
+	keys := make([]Key, len(master))
+	copy(keys, master)
+	return keys, nil
+}
+
+func (s RotationSchedule) Expand(spn *SPN, master []Key) ([]Key, error) {
+	k, err := masterBlock(spn, master)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]Key, spn.Rounds()+1)
+	for i := range keys {
+		keys[i] = Key(rotate(spn, k, s.Shift*i))
+	}
+	return keys, nil
+}
+
+func (s RotationSchedule) Master(spn *SPN, round int, key Key) ([]Key, error) {
+	if err := checkRound(spn, round); err != nil {
+		return nil, err
+	}
+	return []Key{Key(rotate(spn, int(key), -s.Shift*round))}, nil
+}
+
+func (s AESSchedule) Expand(spn *SPN, master []Key) ([]Key, error) {
+	k, err := masterBlock(spn, master)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.SBox) != 1<<uint(spn.Width()) {
+		return nil, fmt.Errorf("heys: aes schedule needs %d-bit S-box", spn.Width())
+	}
+	keys := make([]Key, spn.Rounds()+1)
+	keys[0] = Key(k)
+	for i := 1; i < len(keys); i++ {
+		keys[i] = Key(s.next(spn, int(keys[i-1]), i))
+	}
+	return keys, nil
+}
+
+func (s AESSchedule) Master(spn *SPN, round int, key Key) ([]Key, error) {
+	if err := checkRound(spn, round); err != nil {
+		return nil, err
+	}
+	if len(s.SBox) != 1<<uint(spn.Width()) {
+		return nil, fmt.Errorf("heys: aes schedule needs %d-bit S-box", spn.Width())
+	}
+	if spn.Count() < 2 {
+		return nil, errors.New("heys: aes schedule of one S-box is not invertible")
+	}
+	k := int(key)
+	for i := round; i > 0; i-- {
+		k = s.previous(spn, k, i)
+	}
+	return []Key{Key(k)}, nil
+}
+
+func (s AESSchedule) next(spn *SPN, k, round int) int {
+	w, mask := uint(spn.Width()), 1<<uint(spn.Width())-1
+	word := spn.Nibble(k, 0) ^ s.SBox[spn.Nibble(k, spn.Count()-1)] ^ (round & mask)
+	next := word
+	for j := 1; j < spn.Count(); j++ {
+		word ^= spn.Nibble(k, j)
+		next |= word << (w * uint(j))
+	}
+	return next
+}
+
+func (s AESSchedule) previous(spn *SPN, k, round int) int {
+	w, mask := uint(spn.Width()), 1<<uint(spn.Width())-1
+	previous := 0
+	for j := 1; j < spn.Count(); j++ {
+		previous |= (spn.Nibble(k, j) ^ spn.Nibble(k, j-1)) << (w * uint(j))
+	}
+	first := spn.Nibble(k, 0) ^ s.SBox[spn.Nibble(previous, spn.Count()-1)] ^ (round & mask)
+	return previous | first
+}
+
+// RecoverMaster inverts candidates of round key #round in their order and
+// returns the first master key and its round keys which cipher of spn with
+// sBox encrypts every plaintext x to encrypted[x], candidates are usually the
+// best scored keys of differential or linear attack
+func RecoverMaster(spn *SPN, inverter KeyScheduleInverter, sBox []int, round int, candidates []Key, encrypted []Block) ([]Key, []Key, error) {
+	if len(encrypted) == 0 || len(encrypted) > 1<<uint(spn.BlockSize()) {
+		return nil, nil, fmt.Errorf("heys: invalid count of encrypted blocks %d", len(encrypted))
+	}
+	for _, candidate := range candidates {
+		master, err := inverter.Master(spn, round, candidate)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys, err := inverter.Expand(spn, master)
+		if err != nil {
+			return nil, nil, err
+		}
+		c, err := NewSPNCipher(spn, keys, sBox)
+		if err != nil {
+			return nil, nil, err
+		}
+		x := 0
+		for x < len(encrypted) && c.Encrypt(Block(x)) == encrypted[x] {
+			x++
+		}
+		if x == len(encrypted) {
+			return master, keys, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("heys: none of %d candidates of round key #%d gives master key of ciphertexts", len(candidates), round)
+}
+
+func checkRound(spn *SPN, round int) error {
+	if round < 0 || round > spn.Rounds() {
+		return fmt.Errorf("heys: invalid round %d of round keys from 0 to %d", round, spn.Rounds())
+	}
+	return nil
+}
+
+func masterBlock(spn *SPN, master []Key) (int, error) {
+	if len(master) != 1 {
+		return 0, errors.New("heys: schedule needs one-block master key")
+	}
+	if int(master[0]) >= 1<<uint(spn.BlockSize()) {
+		return 0, fmt.Errorf("heys: master key 0x%x is out of %d-bit range", master[0], spn.BlockSize())
+	}
+	return int(master[0]), nil
+}
+
+func rotate(spn *SPN, k, shift int) int {
+	size := spn.BlockSize()
+	shift = ((shift % size) + size) % size
+	mask := 1<<uint(size) - 1
+	return ((k << uint(shift)) | (k >> uint(size-shift))) & mask
+}
@@ -0,0 +1,66 @@
+package heys
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMasterInvertsExpand(t *testing.T) {
+	spn, err := HeysSPN(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inverters := []KeyScheduleInverter{RotationSchedule{3}, AESSchedule{SBlocks}}
+	r := rand.New(rand.NewSource(1))
+	for _, inverter := range inverters {
+		for i := 0; i < 20; i++ {
+			master := []Key{Key(r.Intn(0x10000))}
+			keys, err := inverter.Expand(spn, master)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for round, key := range keys {
+				m, err := inverter.Master(spn, round, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(m) != 1 || m[0] != master[0] {
+					t.Fatalf("%T: round key #%d 0x%04x gives master %04x, want 0x%04x", inverter, round, key, m, master[0])
+				}
+			}
+		}
+		if _, err := inverter.Master(spn, spn.Rounds()+1, 0); err == nil {
+			t.Fatalf("%T: round %d is accepted", inverter, spn.Rounds()+1)
+		}
+	}
+}
+
+func TestRecoverMaster(t *testing.T) {
+	spn, err := HeysSPN(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule, master := AESSchedule{SBlocks}, []Key{0x3c5a}
+	keys, err := schedule.Expand(spn, master)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewSPNCipher(spn, keys, SBlocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := c.EncryptAll()
+	for _, round := range []int{0, spn.Rounds()} {
+		candidates := []Key{keys[round] ^ 0x0001, keys[round] ^ 0x0100, keys[round]}
+		m, k, err := RecoverMaster(spn, schedule, SBlocks, round, candidates, encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m[0] != master[0] || k[round] != keys[round] {
+			t.Fatalf("round %d: recovered master %04x and keys %04x, want %04x and %04x", round, m, k, master, keys)
+		}
+		if _, _, err := RecoverMaster(spn, schedule, SBlocks, round, candidates[:2], encrypted); err == nil {
+			t.Fatalf("round %d: wrong candidates give master key", round)
+		}
+	}
+}
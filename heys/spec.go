@@ -0,0 +1,104 @@
+package heys
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// Spec is JSON specification of a cipher, zero width and count mean Heys
+// 4-bit S-boxes, empty permutation means TransposePermutation, round keys are
+// expanded from master key if schedule is rotation or aes
+type Spec struct {
+	Width       int    `json:"width,omitempty"`
+	Count       int    `json:"count,omitempty"`
+	Rounds      int    `json:"rounds"`
+	SBox        []int  `json:"sbox"`
+	Permutation []int  `json:"permutation,omitempty"`
+	Keys        []Key  `json:"keys,omitempty"`
+	Schedule    string `json:"schedule,omitempty"`
+	Master      []Key  `json:"master,omitempty"`
+	Shift       int    `json:"shift,omitempty"`
+}
+
+// DefaultSpec returns specification of Heys cipher with Defaultkey and SBlocks
+func DefaultSpec() *Spec {
+	spec := &Spec{
+		Width:       4,
+		Count:       4,
+		Rounds:      len(Defaultkey) - 1,
+		SBox:        make([]int, len(SBlocks)),
+		Permutation: TransposePermutation(4, 4),
+		Keys:        make([]Key, len(Defaultkey)),
+	}
+	copy(spec.SBox, SBlocks)
+	copy(spec.Keys, Defaultkey)
+	return spec
+}
+
+// LoadSpec reads specification from JSON file
+func LoadSpec(path string) (*Spec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec := new(Spec)
+	if err = json.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("heys: %s: %v", path, err)
+	}
+	if _, err = spec.Cipher(); err != nil {
+		return nil, fmt.Errorf("%v in %s", err, path)
+	}
+	return spec, nil
+}
+
+// Save writes specification to JSON file
+func (spec *Spec) Save(path string) error {
+	data, err := json.MarshalIndent(spec, "", "	")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, os.ModePerm)
+}
+
+// SPN returns description of substitution-permutation network
+func (spec *Spec) SPN() (*SPN, error) {
+	width, count := spec.Width, spec.Count
+	if width == 0 {
+		width = 4
+	}
+	if count == 0 {
+		count = 4
+	}
+	var permutation []int
+	if len(spec.Permutation) != 0 {
+		permutation = spec.Permutation
+	}
+	return NewSPN(width, count, spec.Rounds, permutation)
+}
+
+// KeySchedule returns key schedule of specification
+func (spec *Spec) KeySchedule() (KeySchedule, error) {
+	return NewKeySchedule(spec.Schedule, spec.Shift, spec.SBox)
+}
+
+// Cipher returns cipher of specification, S-box is checked to be bijective
+// and its inverse is derived
+func (spec *Spec) Cipher() (*Cipher, error) {
+	spn, err := spec.SPN()
+	if err != nil {
+		return nil, err
+	}
+	keys := spec.Keys
+	if spec.Schedule != "" && spec.Schedule != "independent" {
+		schedule, err := spec.KeySchedule()
+		if err != nil {
+			return nil, err
+		}
+		if keys, err = schedule.Expand(spn, spec.Master); err != nil {
+			return nil, err
+		}
+	}
+	return NewSPNCipher(spn, keys, spec.SBox)
+}
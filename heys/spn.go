@@ -0,0 +1,162 @@
+package heys
+
+import "fmt"
+
+// SPN describes substitution-permutation network: count of S-boxes, bit width
+// of one S-box, bit permutation of a block and count of rounds
+type SPN struct {
+	width       int
+	count       int
+	rounds      int
+	permutation []int
+	inverse     []int
+	// permutation and its inverse as lookup tables by S-box position and value
+	pTable [][]int
+	iTable [][]int
+}
+
+// NewSPN returns SPN description of block up to 16 bits, permutation moves bit
+// i of a block to bit permutation[i], nil permutation is
+// TransposePermutation(width, count)
+func NewSPN(width, count, rounds int, permutation []int) (*SPN, error) {
+	if width < 1 || width > 8 {
+		return nil, fmt.Errorf("heys: invalid S-box width %d", width)
+	}
+	if count < 1 || width*count > 16 {
+		return nil, fmt.Errorf("heys: invalid count of S-boxes %d for %d-bit S-box, block must be up to 16 bits", count, width)
+	}
+	if rounds < 1 {
+		return nil, fmt.Errorf("heys: invalid count of rounds %d", rounds)
+	}
+	if permutation == nil {
+		permutation = TransposePermutation(width, count)
+	}
+	size := width * count
+	if len(permutation) != size {
+		return nil, fmt.Errorf("heys: permutation of %d-bit block must have %d entries, got %d", size, size, len(permutation))
+	}
+	spn := &SPN{
+		width:       width,
+		count:       count,
+		rounds:      rounds,
+		permutation: make([]int, size),
+		inverse:     make([]int, size),
+	}
+	for i := range spn.inverse {
+		spn.inverse[i] = -1
+	}
+	for i, j := range permutation {
+		if j < 0 || j >= size || spn.inverse[j] != -1 {
+			return nil, fmt.Errorf("heys: bit permutation is not bijective at bit %d", i)
+		}
+		spn.permutation[i] = j
+		spn.inverse[j] = i
+	}
+	spn.pTable = spn.table(spn.permutation)
+	spn.iTable = spn.table(spn.inverse)
+	return spn, nil
+}
+
+// HeysSPN returns SPN of Heys cipher: four 4-bit S-boxes and transposition of bits
+func HeysSPN(rounds int) (*SPN, error) {
+	return NewSPN(4, 4, rounds, nil)
+}
+
+// TransposePermutation moves bit j of S-box i to bit i of S-box j, for
+// width == count it is transposition of bit matrix like in Heys cipher
+func TransposePermutation(width, count int) []int {
+	permutation := make([]int, width*count)
+	for i := range permutation {
+		permutation[i] = (i%width)*count + i/width
+	}
+	return permutation
+}
+
+// Width returns bit width of one S-box
+func (spn *SPN) Width() int {
+	return spn.width
+}
+
+// Count returns count of S-boxes in a block
+func (spn *SPN) Count() int {
+	return spn.count
+}
+
+// Rounds returns count of rounds
+func (spn *SPN) Rounds() int {
+	return spn.rounds
+}
+
+// BlockSize returns bit size of a block
+func (spn *SPN) BlockSize() int {
+	return spn.width * spn.count
+}
+
+// Permutation returns copy of bit permutation
+func (spn *SPN) Permutation() []int {
+	permutation := make([]int, len(spn.permutation))
+	copy(permutation, spn.permutation)
+	return permutation
+}
+
+// Substitute applies sBox to every S-box of a block
+func (spn *SPN) Substitute(block int, sBox []int) int {
+	mask := 1<<uint(spn.width) - 1
+	result := 0
+	for i := 0; i < spn.count; i++ {
+		shift := uint(i * spn.width)
+		result |= sBox[(block>>shift)&mask] << shift
+	}
+	return result
+}
+
+// Permute moves bits of a block by permutation
+func (spn *SPN) Permute(block int) int {
+	return spn.apply(spn.pTable, block)
+}
+
+// InversePermute inverts Permute
+func (spn *SPN) InversePermute(block int) int {
+	return spn.apply(spn.iTable, block)
+}
+
+// SingleSBoxBlocks returns blocks with one nonzero S-box, they are default
+// input differences and masks of searches
+func (spn *SPN) SingleSBoxBlocks() []Block {
+	blocks := make([]Block, 0, spn.count*(1<<uint(spn.width)-1))
+	for i := 0; i < spn.count; i++ {
+		for v := 1; v < 1<<uint(spn.width); v++ {
+			blocks = append(blocks, Block(v<<uint(i*spn.width)))
+		}
+	}
+	return blocks
+}
+
+// Nibble returns value of S-box i in a block
+func (spn *SPN) Nibble(block, i int) int {
+	return (block >> uint(i*spn.width)) & (1<<uint(spn.width) - 1)
+}
+
+func (spn *SPN) apply(table [][]int, block int) int {
+	mask := 1<<uint(spn.width) - 1
+	result := 0
+	for i := 0; i < spn.count; i++ {
+		result |= table[i][(block>>uint(i*spn.width))&mask]
+	}
+	return result
+}
+
+func (spn *SPN) table(permutation []int) [][]int {
+	table := make([][]int, spn.count)
+	for i := range table {
+		table[i] = make([]int, 1<<uint(spn.width))
+		for v := range table[i] {
+			for b := 0; b < spn.width; b++ {
+				if (v>>uint(b))&1 == 1 {
+					table[i][v] |= 1 << uint(permutation[i*spn.width+b])
+				}
+			}
+		}
+	}
+	return table
+}
@@ -0,0 +1,147 @@
+package heys
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+)
+
+// size of chunks of data processed by writers and readers
+const chunkSize = 32 * 1024
+
+type (
+	blockWriter struct {
+		w    io.Writer
+		mode cipher.BlockMode
+		buf  []byte
+	}
+	blockReader struct {
+		r    io.Reader
+		mode cipher.BlockMode
+		in   []byte
+		held []byte
+		out  []byte
+		buf  []byte
+		err  error
+	}
+)
+
+// NewEncryptWriter returns writer which encrypts data with c in mode and
+// writes it to w, Close writes the last padded block of ecb and cbc modes and
+// closes w if it is io.Closer
+func NewEncryptWriter(w io.Writer, c *Cipher, mode string, iv []byte) (io.WriteCloser, error) {
+	if err := check(mode, iv); err != nil {
+		return nil, err
+	}
+	b, err := NewBlock(c)
+	if err != nil {
+		return nil, err
+	}
+	if m := newBlockMode(b, mode, iv, true); m != nil {
+		return &blockWriter{w: w, mode: m}, nil
+	}
+	stream, err := newStream(b, mode, iv, true)
+	if err != nil {
+		return nil, err
+	}
+	return &cipher.StreamWriter{S: stream, W: w}, nil
+}
+
+// NewDecryptReader returns reader which decrypts data from r with c in mode,
+// padding of ecb and cbc modes is strictly checked at the end of r
+func NewDecryptReader(r io.Reader, c *Cipher, mode string, iv []byte) (io.Reader, error) {
+	if err := check(mode, iv); err != nil {
+		return nil, err
+	}
+	b, err := NewBlock(c)
+	if err != nil {
+		return nil, err
+	}
+	if m := newBlockMode(b, mode, iv, false); m != nil {
+		return &blockReader{r: r, mode: m, buf: make([]byte, chunkSize)}, nil
+	}
+	stream, err := newStream(b, mode, iv, false)
+	if err != nil {
+		return nil, err
+	}
+	return &cipher.StreamReader{S: stream, R: r}, nil
+}
+
+func (w *blockWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		k := chunkSize - len(w.buf)
+		if k > len(p) {
+			k = len(p)
+		}
+		w.buf, p = append(w.buf, p[:k]...), p[k:]
+		if err := w.flush(len(w.buf) - len(w.buf)%w.mode.BlockSize()); err != nil {
+			return n - len(p), err
+		}
+	}
+	return n, nil
+}
+
+func (w *blockWriter) Close() error {
+	w.buf = Pad(w.buf, w.mode.BlockSize())
+	if err := w.flush(len(w.buf)); err != nil {
+		return err
+	}
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// flush encrypts and writes n bytes of buffer
+func (w *blockWriter) flush(n int) error {
+	if n == 0 {
+		return nil
+	}
+	w.mode.CryptBlocks(w.buf[:n], w.buf[:n])
+	if _, err := w.w.Write(w.buf[:n]); err != nil {
+		return err
+	}
+	w.buf = w.buf[:copy(w.buf, w.buf[n:])]
+	return nil
+}
+
+func (r *blockReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// fill decrypts next chunk and holds back the last block until the end of
+// data to unpad it
+func (r *blockReader) fill() {
+	n, err := r.r.Read(r.buf)
+	r.in = append(r.in, r.buf[:n]...)
+	bs := r.mode.BlockSize()
+	if m := len(r.in) - len(r.in)%bs; m > 0 {
+		r.mode.CryptBlocks(r.in[:m], r.in[:m])
+		r.held = append(r.held, r.in[:m]...)
+		r.in = r.in[:copy(r.in, r.in[m:])]
+		r.out = append(r.out[:0], r.held[:len(r.held)-bs]...)
+		r.held = r.held[:copy(r.held, r.held[len(r.held)-bs:])]
+	}
+	switch {
+	case err == io.EOF && len(r.in) != 0:
+		r.err = errors.New("heys: ciphertext length is not a multiple of block size")
+	case err == io.EOF:
+		data, err := Unpad(r.held, bs)
+		if err != nil {
+			r.err = err
+			return
+		}
+		r.out, r.err = append(r.out, data...), io.EOF
+	case err != nil:
+		r.err = err
+	}
+}
@@ -0,0 +1,161 @@
+package cmdutil
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/urfave/cli"
+)
+
+// DataFlags are flags of encrypt and decrypt commands with default files in
+// and out
+func DataFlags(in, out string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "in",
+			Value: in,
+		},
+		&cli.StringFlag{
+			Name:  "out",
+			Value: out,
+		},
+		&cli.StringFlag{
+			Name:  "key",
+			Usage: "file with little-endian 16-bit round keys, keys of --spec if empty",
+		},
+		&cli.StringFlag{
+			Name:  "mode",
+			Value: "ecb",
+			Usage: strings.Join(heys.Modes, "|"),
+		},
+		&cli.StringFlag{
+			Name:  "iv",
+			Usage: "hex IV for cbc, ctr, ofb and cfb modes",
+		},
+	}
+}
+
+// LoadSpec loads spec of --spec global flag, DefaultSpec if it is empty
+func LoadSpec(c *cli.Context) (*heys.Spec, error) {
+	if c.GlobalString("spec") == "" {
+		return heys.DefaultSpec(), nil
+	}
+	return heys.LoadSpec(c.GlobalString("spec"))
+}
+
+// LoadCipher returns cipher of spec of --spec global flag
+func LoadCipher(c *cli.Context) (*heys.Cipher, error) {
+	spec, err := LoadSpec(c)
+	if err != nil {
+		return nil, err
+	}
+	return spec.Cipher()
+}
+
+// CipherFromFlags returns cipher of spec with round keys of --key file if
+// it is set and IV of --iv flag
+func CipherFromFlags(c *cli.Context) (*heys.Cipher, []byte, error) {
+	spec, err := LoadSpec(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.String("key") != "" {
+		data, err := ioutil.ReadFile(c.String("key"))
+		if err != nil {
+			return nil, nil, err
+		}
+		if spec.Keys, err = heys.ConvertDataToKeys(data); err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", c.String("key"), err)
+		}
+		spec.Schedule = ""
+		spec.Rounds = len(spec.Keys) - 1
+	}
+	cipher, err := spec.Cipher()
+	if err != nil {
+		return nil, nil, err
+	}
+	iv, err := hex.DecodeString(c.String("iv"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("iv: %v", err)
+	}
+	return cipher, iv, nil
+}
+
+// ParseBlocks parses comma separated hex blocks, nil if s is empty
+func ParseBlocks(s string) ([]heys.Block, error) {
+	if s == "" {
+		return nil, nil
+	}
+	fields := strings.Split(s, ",")
+	blocks := make([]heys.Block, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(field), "0x"), 16, 16)
+		if err != nil {
+			return nil, err
+		}
+		blocks[i] = heys.Block(v)
+	}
+	return blocks, nil
+}
+
+// ParseFloats parses comma separated numbers, nil if s is empty
+func ParseFloats(s string) ([]float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	fields := strings.Split(s, ",")
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// Candidates returns round keys scored by attack from the best one
+func Candidates(scores map[heys.Key]int) []heys.Key {
+	keys := make([]heys.Key, 0, len(scores))
+	for k := range scores {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if scores[keys[i]] != scores[keys[j]] {
+			return scores[keys[i]] > scores[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// ReadEncrypted reads ciphertexts of all blocks in order from file
+func ReadEncrypted(file string) ([]heys.Block, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := heys.ConvertDataToBlocks(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return blocks, nil
+}
+
+// Interrupted ends progress bar line if err is cancellation by interrupt
+func Interrupted(err error) error {
+	if err == context.Canceled {
+		fmt.Fprintln(os.Stderr)
+		return errors.New("interrupted")
+	}
+	return err
+}
@@ -3,19 +3,29 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"math"
+	"math/rand"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/mariiatuzovska/cryptanalysis/internal/cmdutil"
 	"github.com/mariiatuzovska/cryptanalysis/linear"
+	"github.com/mariiatuzovska/cryptanalysis/progress"
+	"github.com/mariiatuzovska/cryptanalysis/search"
+	"github.com/mariiatuzovska/cryptanalysis/stats"
 	"github.com/urfave/cli"
 )
 
 var (
-	alpha       = 0xc00
-	beta        = 0x1111
+	alpha       = heys.Block(0xc00)
+	beta        = heys.Block(0x1111)
 	limKeyCount = 12000
 	keyFiles    = []string{}
 )
@@ -29,48 +39,215 @@ func main() {
 	app.Version = "0.0.1"
 	app.Copyright = "2020, mariiatuzovska"
 	app.Authors = []cli.Author{cli.Author{Name: "Tuzovska Mariia"}}
+	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:  "spec",
+			Usage: "JSON cipher specification with S-box, permutation, round keys and rounds, Heys cipher if empty",
+		},
+	}
 	app.Commands = []cli.Command{
 		{
 			Name:  "e",
 			Usage: "encrypt",
+			Flags: cmdutil.DataFlags("community/plain.txt", "community/cipher.txt"),
 			Action: func(c *cli.Context) error {
-				data, err := ioutil.ReadFile("community/plain.txt")
+				cipher, iv, err := cmdutil.CipherFromFlags(c)
 				if err != nil {
 					return err
 				}
-				blocks := heys.ConvertDataToBlocks(data)
-				for i := 0; i < len(blocks); i++ {
-					blocks[i] = heys.Encrypt(blocks[i])
+				in, err := os.Open(c.String("in"))
+				if err != nil {
+					return err
+				}
+				defer in.Close()
+				out, err := os.Create(c.String("out"))
+				if err != nil {
+					return err
 				}
-				data = heys.ConvertBlocksToData(blocks)
-				key := heys.ConvertBlocksToData(heys.Defaultkey)
-				ioutil.WriteFile("community/key.txt", key, os.ModePerm)
-				return ioutil.WriteFile("community/cipher.txt", data, os.ModePerm)
+				w, err := heys.NewEncryptWriter(out, cipher, c.String("mode"), iv)
+				if err != nil {
+					out.Close()
+					return err
+				}
+				if _, err = io.Copy(w, in); err != nil {
+					out.Close()
+					return err
+				}
+				return w.Close()
 			},
 		},
 		{
 			Name:  "d",
 			Usage: "decrypt",
+			Flags: cmdutil.DataFlags("community/cipher.txt", "community/pt2.txt"),
 			Action: func(c *cli.Context) error {
-				data, err := ioutil.ReadFile("community/cipher.txt")
+				cipher, iv, err := cmdutil.CipherFromFlags(c)
+				if err != nil {
+					return err
+				}
+				in, err := os.Open(c.String("in"))
 				if err != nil {
 					return err
 				}
-				blocks := heys.ConvertDataToBlocks(data)
-				for i := 0; i < len(blocks); i++ {
-					blocks[i] = heys.Decrypt(blocks[i])
+				defer in.Close()
+				r, err := heys.NewDecryptReader(in, cipher, c.String("mode"), iv)
+				if err != nil {
+					return err
+				}
+				out, err := os.Create(c.String("out"))
+				if err != nil {
+					return err
+				}
+				if _, err = io.Copy(out, r); err != nil {
+					out.Close()
+					return err
+				}
+				return out.Close()
+			},
+		},
+		{
+			Name:  "master",
+			Usage: "recovers master key with key schedule of --spec from round key or from keys found by attack",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "key",
+					Usage: "hex round key, candidates in --keys are checked with --encrypted if empty",
+				},
+				&cli.IntFlag{
+					Name:  "round",
+					Usage: "index of round key from 0, the first round key found by attack by default",
+				},
+				&cli.StringFlag{
+					Name:  "keys",
+					Value: "community/keys_attack_all.json",
+					Usage: "round keys scored by attack",
+				},
+				&cli.StringFlag{
+					Name:  "encrypted",
+					Usage: "ciphertexts of all blocks in order",
+					Value: "community/encrypted.txt",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				spec, err := cmdutil.LoadSpec(c)
+				if err != nil {
+					return err
+				}
+				spn, err := spec.SPN()
+				if err != nil {
+					return err
+				}
+				schedule, err := spec.KeySchedule()
+				if err != nil {
+					return err
+				}
+				inverter, ok := schedule.(heys.KeyScheduleInverter)
+				if !ok {
+					return fmt.Errorf("%T can't recover master key", schedule)
+				}
+				round := c.Int("round")
+				var master, keys []heys.Key
+				if c.String("key") != "" {
+					key, err := strconv.ParseUint(strings.TrimPrefix(c.String("key"), "0x"), 16, 16)
+					if err != nil {
+						return err
+					}
+					if master, err = inverter.Master(spn, round, heys.Key(key)); err != nil {
+						return err
+					}
+					if keys, err = schedule.Expand(spn, master); err != nil {
+						return err
+					}
+				} else {
+					scores := make(map[heys.Key]int)
+					file, err := ioutil.ReadFile(c.String("keys"))
+					if err != nil {
+						return err
+					}
+					if err = json.Unmarshal(file, &scores); err != nil {
+						return err
+					}
+					encrypted, err := cmdutil.ReadEncrypted(c.String("encrypted"))
+					if err != nil {
+						return err
+					}
+					if master, keys, err = heys.RecoverMaster(spn, inverter, spec.SBox, round, cmdutil.Candidates(scores), encrypted); err != nil {
+						return err
+					}
+				}
+				for _, k := range master {
+					fmt.Println(fmt.Sprintf("master 0x%04x", k))
 				}
-				data = heys.ConvertBlocksToData(blocks)
-				key := heys.ConvertBlocksToData(heys.Defaultkey)
-				ioutil.WriteFile("community/key.txt", key, os.ModePerm)
-				return ioutil.WriteFile("community/pt2.txt", data, os.ModePerm)
+				for i, k := range keys {
+					fmt.Println(fmt.Sprintf("round key #%d 0x%04x", i, k))
+				}
+				return nil
 			},
 		},
 		{
 			Name:  "search",
 			Usage: "search for linear approximations",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "alphas",
+					Usage: "comma separated hex input masks, masks of one S-box if empty",
+				},
+				&cli.StringFlag{
+					Name:  "thresholds",
+					Usage: "comma separated min squared correlations after every round, the last one is used for remaining rounds",
+				},
+				&cli.IntFlag{
+					Name:  "rounds",
+					Usage: "count of rounds, rounds of cipher minus the last one if 0",
+				},
+				&cli.IntFlag{
+					Name:  "workers",
+					Usage: "count of alphas searched concurrently, count of CPU if 0",
+				},
+				&cli.StringFlag{
+					Name:  "checkpoint",
+					Usage: "file where state is saved to resume after interruption, no checkpoints if empty",
+					Value: "community/approximations.checkpoint.json",
+				},
+				&cli.DurationFlag{
+					Name:  "checkpoint-interval",
+					Value: time.Minute,
+				},
+				&cli.BoolFlag{
+					Name:  "resume",
+					Usage: "continues from --checkpoint",
+				},
+			},
 			Action: func(c *cli.Context) error {
-				m := linear.Search()
+				cipher, err := cmdutil.LoadCipher(c)
+				if err != nil {
+					return err
+				}
+				opts := linear.SearchOptions{
+					Options: search.Options{
+						Cipher:             cipher,
+						Rounds:             c.Int("rounds"),
+						Workers:            c.Int("workers"),
+						Checkpoint:         c.String("checkpoint"),
+						CheckpointInterval: c.Duration("checkpoint-interval"),
+						Resume:             c.Bool("resume"),
+					},
+				}
+				if opts.Alphas, err = cmdutil.ParseBlocks(c.String("alphas")); err != nil {
+					return err
+				}
+				if opts.Thresholds, err = cmdutil.ParseFloats(c.String("thresholds")); err != nil {
+					return err
+				}
+				ctx, cancel := progress.InterruptContext()
+				defer cancel()
+				opts.Progress = progress.Bar(os.Stderr, 40)
+				t1 := time.Now()
+				m, err := linear.Search(ctx, opts)
+				if err != nil {
+					return cmdutil.Interrupted(err)
+				}
+				fmt.Println("Runs", time.Since(t1).Milliseconds(), "ms")
 				arr, err := json.MarshalIndent(m, "", "	")
 				if err != nil {
 					log.Fatal(err)
@@ -82,7 +259,7 @@ func main() {
 			Name:  "show",
 			Usage: "shows approximations that has been found",
 			Action: func(c *cli.Context) error {
-				approximations := make(map[int]map[int]float64)
+				approximations := make(map[heys.Block]map[heys.Block]float64)
 				file, err := ioutil.ReadFile("community/approximations.json")
 				if err != nil {
 					return err
@@ -91,12 +268,12 @@ func main() {
 				if err != nil {
 					return err
 				}
-				fmt.Println("\nFound approximations:\n")
-				sortedMap, probs := make(map[float64]map[int]int), make([]float64, 0)
+				fmt.Print("\nFound approximations:\n\n")
+				sortedMap, probs := make(map[float64]map[heys.Block]heys.Block), make([]float64, 0)
 				for alpha, aprox := range approximations {
 					for beta, prob := range aprox {
 						probs = append(probs, prob)
-						sortedMap[prob] = map[int]int{alpha: beta}
+						sortedMap[prob] = map[heys.Block]heys.Block{alpha: beta}
 					}
 				}
 				sort.Float64s(probs)
@@ -113,11 +290,325 @@ func main() {
 				return nil
 			},
 		},
+		{
+			Name:  "best-trail",
+			Usage: "finds linear trail with the greatest squared correlation by Matsui branch and bound search over S-box LAT",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:  "rounds",
+					Usage: "count of rounds, rounds of cipher minus the last one if 0",
+				},
+				&cli.StringFlag{
+					Name:  "approximations",
+					Value: "community/approximations.json",
+					Usage: "approximations of search compared with the best trail, no comparison if file does not exist",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				spec, err := cmdutil.LoadSpec(c)
+				if err != nil {
+					return err
+				}
+				spn, err := spec.SPN()
+				if err != nil {
+					return err
+				}
+				rounds := c.Int("rounds")
+				if rounds == 0 {
+					rounds = spn.Rounds() - 1
+				}
+				ctx, cancel := progress.InterruptContext()
+				defer cancel()
+				t1 := time.Now()
+				trail, bounds, err := linear.BestTrail(ctx, spn, spec.SBox, rounds)
+				if err != nil {
+					return cmdutil.Interrupted(err)
+				}
+				fmt.Println("Runs", time.Since(t1).Milliseconds(), "ms")
+				for r, w := range bounds {
+					fmt.Println(fmt.Sprintf("best trail of %d rounds -- squared correlation 2^-%.2f", r+1, w))
+				}
+				fmt.Println(fmt.Sprintf("0x%04x -- 0x%04x -- correlation %f -- bias %f -- squared correlation %f",
+					trail.Masks[0], trail.Masks[rounds], trail.Correlation, trail.Bias(), trail.Potential()))
+				for r := range trail.Correlations {
+					fmt.Println(fmt.Sprintf("  round %d 0x%04x -> 0x%04x -- %d active S-boxes -- %f",
+						r+1, trail.Masks[r], trail.Masks[r+1], trail.Active[r], trail.Correlations[r]))
+				}
+				file, err := os.Open(c.String("approximations"))
+				if os.IsNotExist(err) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				approximations, err := linear.ReadApproximations(file)
+				if err != nil {
+					return err
+				}
+				var alpha, beta heys.Block
+				max, above := 0.0, 0
+				for a, bMap := range approximations {
+					for b, p := range bMap {
+						if p > max {
+							alpha, beta, max = a, b, p
+						}
+						if p >= trail.Potential() {
+							above++
+						}
+					}
+				}
+				fmt.Println(fmt.Sprintf("%s: the best 0x%04x -- 0x%04x -- %f, %d approximations are not below the best trail",
+					c.String("approximations"), alpha, beta, max, above))
+				return nil
+			},
+		},
+		{
+			Name:  "hull",
+			Usage: "sums trails of approximation alpha and beta and compares it with approximations of search and the best trail",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "alpha",
+					Usage: "hex input mask, all approximations of --approximations if empty",
+				},
+				&cli.StringFlag{
+					Name:  "beta",
+					Usage: "hex output mask",
+				},
+				&cli.StringFlag{
+					Name:  "approximations",
+					Value: "community/approximations.json",
+				},
+				&cli.IntFlag{
+					Name:  "rounds",
+					Usage: "count of rounds, rounds of cipher minus the last one if 0",
+				},
+				&cli.Float64Flag{
+					Name:  "weight",
+					Value: 24,
+					Usage: "max weight of trails, -log2 of their squared correlation",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				spec, err := cmdutil.LoadSpec(c)
+				if err != nil {
+					return err
+				}
+				spn, err := spec.SPN()
+				if err != nil {
+					return err
+				}
+				rounds := c.Int("rounds")
+				if rounds == 0 {
+					rounds = spn.Rounds() - 1
+				}
+				approximations := make(map[heys.Block]map[heys.Block]float64)
+				file, err := os.Open(c.String("approximations"))
+				if err != nil && (c.String("alpha") == "" || !os.IsNotExist(err)) {
+					return err
+				}
+				if err == nil {
+					approximations, err = linear.ReadApproximations(file)
+					file.Close()
+					if err != nil {
+						return err
+					}
+				}
+				pairs := [][2]heys.Block{}
+				if c.String("alpha") != "" {
+					blocks, err := cmdutil.ParseBlocks(c.String("alpha") + "," + c.String("beta"))
+					if err != nil {
+						return err
+					}
+					pairs = append(pairs, [2]heys.Block{blocks[0], blocks[1]})
+				} else {
+					for a, bMap := range approximations {
+						for b := range bMap {
+							pairs = append(pairs, [2]heys.Block{a, b})
+						}
+					}
+					sort.Slice(pairs, func(i, j int) bool {
+						return approximations[pairs[i][0]][pairs[i][1]] > approximations[pairs[j][0]][pairs[j][1]]
+					})
+				}
+				ctx, cancel := progress.InterruptContext()
+				defer cancel()
+				t1 := time.Now()
+				for _, pair := range pairs {
+					hull, err := linear.SumTrails(ctx, spn, spec.SBox, rounds, pair[0], pair[1], math.Exp2(-c.Float64("weight")))
+					if err != nil {
+						return cmdutil.Interrupted(err)
+					}
+					fmt.Println(fmt.Sprintf("0x%04x -- 0x%04x -- search %f -- hull %f of %d trails -- best trail %f",
+						pair[0], pair[1], approximations[pair[0]][pair[1]], hull.Potential, hull.Trails, hull.Best.Potential()))
+				}
+				fmt.Println("Runs", time.Since(t1).Milliseconds(), "ms")
+				return nil
+			},
+		},
+		{
+			Name:  "verify",
+			Usage: "measures squared correlation of approximation alpha and beta on rounds of cipher with random keys",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "alpha",
+					Value: "0001",
+				},
+				&cli.StringFlag{
+					Name:  "beta",
+					Value: "0880",
+				},
+				&cli.StringFlag{
+					Name:  "approximations",
+					Value: "community/approximations.json",
+				},
+				&cli.IntFlag{
+					Name:  "rounds",
+					Usage: "count of rounds, rounds of cipher minus the last one if 0",
+				},
+				&cli.IntFlag{
+					Name:  "keys",
+					Value: stats.Keys,
+					Usage: "count of random keys",
+				},
+				&cli.IntFlag{
+					Name:  "texts",
+					Usage: "count of random plaintexts of every key, all plaintexts if 0",
+				},
+				&cli.Int64Flag{
+					Name:  "seed",
+					Value: 1,
+				},
+			},
+			Action: func(c *cli.Context) error {
+				blocks, err := cmdutil.ParseBlocks(c.String("alpha") + "," + c.String("beta"))
+				if err != nil {
+					return err
+				}
+				cipher, err := cmdutil.LoadCipher(c)
+				if err != nil {
+					return err
+				}
+				rounds := c.Int("rounds")
+				if rounds == 0 {
+					rounds = cipher.Rounds() - 1
+				}
+				approximations := make(map[heys.Block]map[heys.Block]float64)
+				file, err := os.Open(c.String("approximations"))
+				if err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				if err == nil {
+					approximations, err = linear.ReadApproximations(file)
+					file.Close()
+					if err != nil {
+						return err
+					}
+				}
+				ctx, cancel := progress.InterruptContext()
+				defer cancel()
+				hull, err := linear.SumTrails(ctx, cipher.SPN(), cipher.SBox(), rounds, blocks[0], blocks[1], math.Exp2(-24))
+				if err != nil {
+					return cmdutil.Interrupted(err)
+				}
+				opts := linear.VerifyOptions{
+					Cipher:   cipher,
+					Rounds:   rounds,
+					Keys:     c.Int("keys"),
+					Texts:    c.Int("texts"),
+					Rand:     rand.New(rand.NewSource(c.Int64("seed"))),
+					Progress: progress.Bar(os.Stderr, 40),
+				}
+				t1 := time.Now()
+				v, err := linear.Verify(ctx, blocks[0], blocks[1], opts)
+				if err != nil {
+					return cmdutil.Interrupted(err)
+				}
+				fmt.Println("Runs", time.Since(t1).Milliseconds(), "ms")
+				fmt.Println(fmt.Sprintf("0x%04x -- 0x%04x -- search %f -- hull %f of %d trails -- best trail %f",
+					blocks[0], blocks[1], approximations[blocks[0]][blocks[1]], hull.Potential, hull.Trails, hull.Best.Potential()))
+				fmt.Println(fmt.Sprintf("measured %f -- 95%% interval [%f, %f] -- bias %f -- keys from %f to %f -- %d keys of %d texts",
+					v.Potential, v.Low, v.High, v.Bias, v.Min, v.Max, v.Keys, v.Texts))
+				return nil
+			},
+		},
 		{
 			Name:  "attack",
 			Usage: "finds keys for all approximation alpha and beta in community/approximations.json",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "approximations",
+					Value: "community/approximations.json",
+				},
+				&cli.StringFlag{
+					Name:  "encrypted",
+					Usage: "ciphertexts of all blocks in order",
+					Value: "community/encrypted.txt",
+				},
+				&cli.IntFlag{
+					Name:  "texts",
+					Usage: "count of random plaintexts, 8500 if 0",
+				},
+				&cli.IntFlag{
+					Name:  "threshold",
+					Usage: "min score of a key, 12000 if 0",
+				},
+				&cli.Int64Flag{
+					Name:  "seed",
+					Usage: "seed of random plaintexts, random if 0",
+				},
+				&cli.StringFlag{
+					Name:  "checkpoint",
+					Usage: "file where state is saved to resume after interruption, no checkpoints if empty",
+					Value: "community/attack.checkpoint.json",
+				},
+				&cli.DurationFlag{
+					Name:  "checkpoint-interval",
+					Value: time.Minute,
+				},
+				&cli.BoolFlag{
+					Name:  "resume",
+					Usage: "continues from --checkpoint",
+				},
+			},
 			Action: func(c *cli.Context) error {
-				m := linear.Attack()
+				cipher, err := cmdutil.LoadCipher(c)
+				if err != nil {
+					return err
+				}
+				file, err := os.Open(c.String("approximations"))
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				approximations, err := linear.ReadApproximations(file)
+				if err != nil {
+					return err
+				}
+				encrypted, err := cmdutil.ReadEncrypted(c.String("encrypted"))
+				if err != nil {
+					return err
+				}
+				opts := linear.AttackOptions{
+					Cipher:             cipher,
+					Texts:              c.Int("texts"),
+					Threshold:          c.Int("threshold"),
+					Progress:           progress.Bar(os.Stderr, 40),
+					Checkpoint:         c.String("checkpoint"),
+					CheckpointInterval: c.Duration("checkpoint-interval"),
+					Resume:             c.Bool("resume"),
+				}
+				if c.Int64("seed") != 0 {
+					opts.Rand = rand.New(rand.NewSource(c.Int64("seed")))
+				}
+				ctx, cancel := progress.InterruptContext()
+				defer cancel()
+				t1 := time.Now()
+				m, err := linear.Attack(ctx, approximations, encrypted, opts)
+				if err != nil {
+					return cmdutil.Interrupted(err)
+				}
+				fmt.Println("Runs", time.Since(t1).Milliseconds(), "ms")
 				arr, err := json.MarshalIndent(m, "", "	")
 				if err != nil {
 					log.Fatal(err)
@@ -134,7 +625,7 @@ func main() {
 				},
 			},
 			Action: func(c *cli.Context) error {
-				keys := make(map[int]int)
+				keys := make(map[heys.Key]int)
 				var fileName string
 				if c.String("file") != "" {
 					fileName = c.String("file")
@@ -149,7 +640,7 @@ func main() {
 				if err != nil {
 					log.Fatal(err)
 				}
-				newMap := make(map[int]int)
+				newMap := make(map[int]heys.Key)
 				sortedCounts := make([]int, 0)
 				for Key, count := range keys {
 					if count > limKeyCount {
@@ -169,5 +660,7 @@ func main() {
 		},
 	}
 
-	app.Run(os.Args)
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -1,24 +1,28 @@
 package linear
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"io"
 	"math"
 	"math/rand"
+	"reflect"
 	"runtime"
 	"sort"
-	"sync"
 	"time"
 
+	"github.com/mariiatuzovska/cryptanalysis/checkpoint"
 	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/mariiatuzovska/cryptanalysis/progress"
+	"github.com/mariiatuzovska/cryptanalysis/sbox"
+	"github.com/mariiatuzovska/cryptanalysis/search"
 )
 
 type (
 	linearResponse struct {
-		alpha       int
-		probability map[int]float64
+		alpha       heys.Block
+		probability map[heys.Block]float64
 	}
 )
 
@@ -26,247 +30,457 @@ var (
 	limValues     = []float64{0.00015, 0.00015, 0.00015, 0.00015, 0.00012}
 	limConcurency = 12000
 	countOfText   = 8500
-	alphas        = []int{
-		0x0001, 0x0002, 0x0003, 0x0004, 0x0005, 0x0006, 0x0007, 0x0008, 0x0009, 0x000a, 0x000b, 0x000c, 0x000d, 0x000e, 0x000f,
-		0x0010, 0x0020, 0x0030, 0x0040, 0x0050, 0x0060, 0x0070, 0x0080, 0x0090, 0x00a0, 0x00b0, 0x00c0, 0x00d0, 0x00e0, 0x00f0,
-		0x0100, 0x0200, 0x0300, 0x0400, 0x0500, 0x0600, 0x0700, 0x0800, 0x0900, 0x0a00, 0x0b00, 0x0c00, 0x0d00, 0x0e00, 0x0f00,
-		0x1000, 0x2000, 0x3000, 0x4000, 0x5000, 0x6000, 0x7000, 0x8000, 0x9000, 0xa000, 0xb000, 0xc000, 0xd000, 0xe000, 0xf000}
 )
 
-func Attack() *map[int]int {
+type (
+	// SearchOptions are parameters of Search, zero fields take default
+	// values, alphas are input masks and thresholds are min squared
+	// correlations of approximations after every round, limValues if empty
+	SearchOptions struct {
+		search.Options
+	}
+	// AttackOptions are parameters of Attack, zero fields take default values
+	AttackOptions struct {
+		// Cipher which first round is used, heys.DefaultCipher() if nil
+		Cipher *heys.Cipher
+		// Texts is count of random plaintexts, countOfText if zero
+		Texts int
+		// Threshold is min score of a key in result, limConcurency if zero
+		Threshold int
+		// Rand chooses plaintexts, source of math/rand package if nil
+		Rand *rand.Rand
+		// Progress receives count of keys tested for all approximations if
+		// not nil
+		Progress progress.Func
+		// Checkpoint is path of file where plaintexts and scores of keys are
+		// saved every CheckpointInterval and on cancellation, it is removed
+		// when attack is done, no checkpoints if empty
+		Checkpoint string
+		// CheckpointInterval is one minute if zero
+		CheckpointInterval time.Duration
+		// Resume continues attack from Checkpoint if it exists
+		Resume bool
+	}
+	// approximation is input mask alpha and output mask beta of rounds with
+	// squared correlation
+	approximation struct {
+		alpha, beta heys.Block
+		probability float64
+	}
+)
 
-	t1 := time.Now()
+// ReadApproximations reads approximations in JSON as written by search
+// command, approximations[alpha][beta] is squared correlation
+func ReadApproximations(r io.Reader) (map[heys.Block]map[heys.Block]float64, error) {
+	approximations := make(map[heys.Block]map[heys.Block]float64)
+	if err := json.NewDecoder(r).Decode(&approximations); err != nil {
+		return nil, fmt.Errorf("linear: approximations: %v", err)
+	}
+	return approximations, nil
+}
 
-	texts, encryptedOneTime := make(map[int]bool), heys.EncryptAll()
-	for i := 0; i < countOfText; i++ {
-		x := rand.Int() & 0xffff
-		if _, exist := texts[x]; !exist {
-			texts[x] = true
-			i++
-		}
-		i--
+// withDefaults returns copy of options with default values of zero fields
+func (opts AttackOptions) withDefaults() (AttackOptions, error) {
+	if opts.Cipher == nil {
+		opts.Cipher = heys.DefaultCipher()
+	}
+	if opts.Texts == 0 {
+		opts.Texts = countOfText
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = limConcurency
+	}
+	if opts.CheckpointInterval == 0 {
+		opts.CheckpointInterval = time.Minute
 	}
+	if opts.Texts < 1 || opts.Texts > opts.Cipher.Size() {
+		return opts, fmt.Errorf("linear: invalid count of texts %d for %d-bit block", opts.Texts, opts.Cipher.SPN().BlockSize())
+	}
+	return opts, nil
+}
 
-	// encrypted := heys.EncryptAllWithKey()
-	data, err := ioutil.ReadFile("community/encrypted.txt")
-	if err != nil {
-		log.Fatal(err)
+func (opts AttackOptions) intn(n int) int {
+	if opts.Rand == nil {
+		return rand.Intn(n)
 	}
-	encrypted := heys.ConvertDataToBlocks(data)
+	return opts.Rand.Intn(n)
+}
+
+// Attack scores first round keys with approximations and encrypted, where
+// encrypted[x] is ciphertext of plaintext x, and returns keys which score is
+// greater than opts.Threshold
+func Attack(ctx context.Context, approximations map[heys.Block]map[heys.Block]float64, encrypted []heys.Block, opts AttackOptions) (*map[heys.Key]int, error) {
 
-	approximations, scalars := make(map[int]map[int]float64), make([]int, 0x10000)
-	file, err := ioutil.ReadFile("community/approximations.json")
+	opts, err := opts.withDefaults()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	err = json.Unmarshal(file, &approximations)
-	if err != nil {
-		log.Fatal(err)
+	size := opts.Cipher.Size()
+	if len(encrypted) != size {
+		return nil, fmt.Errorf("linear: %d encrypted blocks, expected %d", len(encrypted), size)
 	}
+	for x, y := range encrypted {
+		if int(y) >= size {
+			return nil, fmt.Errorf("linear: ciphertext 0x%04x of plaintext 0x%04x is out of %d-bit block", y, x, opts.Cipher.SPN().BlockSize())
+		}
+	}
+	encryptedOneTime := opts.Cipher.EncryptRoundAll()
 
-	for i := 0; i < 0x10000; i++ {
+	scalars := make([]int, size)
+	for i := 0; i < size; i++ {
 		с := 0
-		for j := 0; j < 16; j++ {
-			if (i>>j)&1 == 1 {
+		for j := 0; j < opts.Cipher.SPN().BlockSize(); j++ {
+			if (i>>uint(j))&1 == 1 {
 				с++
 			}
 		}
 		scalars[i] = с & 1
 	}
 
-	sortedMap, probs := make(map[float64]map[int]int), make([]float64, 0)
+	sorted := make([]approximation, 0)
 	for alpha, aprox := range approximations {
 		for beta, prob := range aprox {
-			probs = append(probs, prob)
-			sortedMap[prob] = map[int]int{alpha: beta}
+			if int(alpha) >= size || int(beta) >= size {
+				return nil, fmt.Errorf("linear: approximation 0x%04x -- 0x%04x is out of %d-bit block", alpha, beta, opts.Cipher.SPN().BlockSize())
+			}
+			sorted = append(sorted, approximation{alpha, beta, prob})
 		}
 	}
-	sort.Float64s(probs)
-	if len(sortedMap) != len(probs) {
-		log.Fatal("Sort failed")
-	}
-
-	fmt.Println(fmt.Sprintf("Starting to process %d aproximations", len(probs)))
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].probability != sorted[j].probability {
+			return sorted[i].probability > sorted[j].probability
+		}
+		if sorted[i].alpha != sorted[j].alpha {
+			return sorted[i].alpha < sorted[j].alpha
+		}
+		return sorted[i].beta < sorted[j].beta
+	})
 
-	keyCandidate := make([]int, 0x10000)
-	for x := 0; x < 0x10000; x++ {
-		keyCandidate[x] = 0
+	state, err := loadAttackCheckpoint(opts, sorted)
+	if err != nil {
+		return nil, err
+	}
+	texts, keyCandidate := state.Texts, state.Candidates
+	save := func() error {
+		if opts.Checkpoint == "" {
+			return nil
+		}
+		return checkpoint.Save(opts.Checkpoint, state)
 	}
 
-	for j := len(probs) - 1; j > -1; j-- {
-		aprox := sortedMap[probs[j]]
-		for alpha, beta := range aprox {
-			fmt.Println(fmt.Sprintf("Approximating 0x%04x -- 0x%04x with probability -- %f -- expected %d", alpha, beta, probs[j], j))
-			res := make([]int, 0x10000)
-			for key := 0; key < 0x10000; key++ {
-				E := 0 // кол-во единиц
-				for block := range texts {
-					if (scalars[alpha&encryptedOneTime[block^key]] ^ scalars[beta&encrypted[block]]) == 1 {
-						E++
-					}
+	tracker := progress.NewTracker(opts.Progress, "attack", len(sorted)*size)
+	tracker.Resume(0, 0, state.Next*size)
+	saved := time.Now()
+
+	for ; state.Next < len(sorted); state.Next++ {
+		alpha, beta := sorted[state.Next].alpha, sorted[state.Next].beta
+		res := make([]int, size)
+		for key := 0; key < size; key++ {
+			if ctx.Err() != nil {
+				if err := save(); err != nil {
+					return nil, err
 				}
-				U := math.Abs(float64(countOfText - E - E))
-				res[key] = int(U)
+				return nil, ctx.Err()
 			}
-			maxU := 0
-			for key := 0; key < 0x10000; key++ {
-				if res[key] > maxU {
-					maxU = res[key]
+			E := 0 // кол-во единиц
+			for _, block := range texts {
+				if (scalars[alpha&encryptedOneTime[block^heys.Block(key)]] ^ scalars[beta&encrypted[block]]) == 1 {
+					E++
 				}
 			}
-			var limU float64 = 0.7 * float64(maxU)
-			for key := 0; key < 0x10000; key++ {
-				if res[key] > int(limU) {
-					keyCandidate[key] += res[key]
-				}
+			U := math.Abs(float64(len(texts) - E - E))
+			res[key] = int(U)
+			tracker.Add(0, 0, 1)
+		}
+		maxU := 0
+		for key := 0; key < size; key++ {
+			if res[key] > maxU {
+				maxU = res[key]
+			}
+		}
+		var limU float64 = 0.7 * float64(maxU)
+		for key := 0; key < size; key++ {
+			if res[key] > int(limU) {
+				keyCandidate[key] += res[key]
+			}
+		}
+		if opts.Checkpoint != "" && time.Since(saved) >= opts.CheckpointInterval {
+			state.Next++
+			err := save()
+			state.Next--
+			if err != nil {
+				return nil, err
 			}
+			saved = time.Now()
 		}
 	}
-
-	result := make(map[int]int)
-	for x := 0; x < 0x10000; x++ {
-		if keyCandidate[x] > limConcurency {
-			result[x] = keyCandidate[x]
+	if opts.Checkpoint != "" {
+		if err := checkpoint.Remove(opts.Checkpoint); err != nil {
+			return nil, err
 		}
 	}
 
-	t2 := time.Now().Sub(t1)
-	fmt.Println("Runs", t2.Milliseconds(), "ms")
+	result := make(map[heys.Key]int)
+	for x := 0; x < size; x++ {
+		if keyCandidate[x] > opts.Threshold {
+			result[heys.Key(x)] = keyCandidate[x]
+		}
+	}
 
-	return &result
+	return &result, nil
 }
 
-func Search() *map[int]map[int]float64 {
+// terms name input masks and squared correlations in errors of search
+var terms = search.Terms{Input: "input mask", Measure: "squared correlation"}
+
+// withDefaults returns copy of options with default values of zero fields
+func (opts SearchOptions) withDefaults() (SearchOptions, error) {
+	var err error
+	opts.Options, err = opts.Options.WithDefaults(limValues, terms)
+	return opts, err
+}
 
-	t1 := time.Now()
+// Search returns squared correlations of approximations from every alpha of
+// opts over opts.Rounds rounds. Squared correlation of approximation is sum
+// of squared correlations of all its trails which masks after every round
+// are not below thresholds, so it estimates potential of linear hull that
+// SumTrails enumerates for one alpha and beta
+func Search(ctx context.Context, opts SearchOptions) (*map[heys.Block]map[heys.Block]float64, error) {
 
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
 	numCPU := runtime.NumCPU()
 	runtime.GOMAXPROCS(numCPU)
-	responseChan := make(chan linearResponse, len(alphas))
+	s, err := sbox.New(opts.Cipher.SBox())
+	if err != nil {
+		return nil, err
+	}
+	state := search.NewState(opts.Options, 0)
+	if err := state.Load(opts.Options, state); err != nil {
+		return nil, err
+	}
+	saver := search.NewSaver(opts.Options, state)
+	defer saver.Stop()
+	size, spn := opts.Cipher.Size(), opts.Cipher.SPN()
+	alphaChan := make(chan heys.Block, len(opts.Alphas))
+	responseChan := make(chan linearResponse, len(opts.Alphas))
+	table := linearApproximationTable(s)
+	tracker := progress.NewTracker(opts.Progress, "search", len(opts.Alphas)*opts.Rounds)
+	result := make(map[heys.Block]map[heys.Block]float64)
+	rounds, alphas := 0, len(state.Done)
+	for alpha, res := range state.Done {
+		result[alpha] = res
+		rounds += opts.Rounds
+	}
+	for _, g := range state.Gamma {
+		rounds += g.Round
+		if g.Round == opts.Rounds {
+			alphas++
+		}
+	}
+	tracker.Resume(rounds, alphas, 0)
+	for _, alpha := range opts.Alphas {
+		if _, done := state.Done[alpha]; !done {
+			alphaChan <- alpha
+		}
+	}
+	close(alphaChan)
 
-	for _, alph := range alphas {
+	for w := 0; w < opts.Workers; w++ {
 
-		go func(alpha int, resp chan linearResponse) {
+		go func(alphas chan heys.Block, resp chan linearResponse) {
 
-			gamma, g := make([]float64, 0x10000), make([]float64, 0x10000)
-			for x := 0; x < 0x10000; x++ {
-				gamma[x] = -1.0
-			}
-			gamma[alpha] = 1.0
-			for round := 1; round < 6; round++ {
-				for x := 0; x < 0x10000; x++ {
-					g[x] = -1.0
+			gamma, g := make([]float64, size), make([]float64, size)
+			for alpha := range alphas {
+				for x := 0; x < size; x++ {
+					gamma[x] = -1.0
+				}
+				gamma[alpha] = 1.0
+				saver.Lock()
+				saved, resumed := state.Gamma[alpha]
+				saver.Unlock()
+				if resumed {
+					gamma[alpha] = -1.0
+					for x, p := range saved.Gamma {
+						gamma[x] = p
+					}
 				}
-				for i := 0; i < 0x10000; i++ {
-					if gamma[i] < 0.0 {
-						continue
+				for round := saved.Round + 1; round <= opts.Rounds; round++ {
+					if ctx.Err() != nil {
+						return
+					}
+					for x := 0; x < size; x++ {
+						g[x] = -1.0
+					}
+					for i := 0; i < size; i++ {
+						if gamma[i] < 0.0 {
+							continue
+						}
+						if i&0xfff == 0 && ctx.Err() != nil {
+							return
+						}
+						approximations := approximate(i, spn, table)
+						for block, probNum := range approximations {
+							p := g[block]
+							if p < 0.0 {
+								p = 0.0
+							}
+							corelation := float64(1.0) - float64(2)*(float64(probNum)/float64(size))
+							g[block] = p + (corelation * corelation * gamma[i])
+						}
 					}
-					approximations := approximate(i)
-					for block, probNum := range approximations {
-						p := g[block]
-						if p < 0.0 {
-							p = 0.0
+					for x := 0; x < size; x++ {
+						gamma[x] = -1.0
+						if g[x] > opts.Threshold(round) {
+							gamma[x] = g[x]
 						}
-						corelation := float64(1.0) - float64(2)*(float64(probNum)/float64(0x10000))
-						g[block] = p + (corelation * corelation * gamma[i])
 					}
-				}
-				for x := 0; x < 0x10000; x++ {
-					gamma[x] = -1.0
-					if g[x] > limValues[round-1] {
-						gamma[x] = g[x]
+					saver.Lock()
+					state.Gamma[alpha] = search.Gamma{Round: round, Gamma: sparse(gamma)}
+					saver.Unlock()
+					if round == opts.Rounds {
+						tracker.Add(1, 1, 0)
+					} else {
+						tracker.Add(1, 0, 0)
 					}
 				}
-			}
-			res := make(map[int]float64)
-			for x := 0; x < 0x10000; x++ {
-				if gamma[x] > 0.0 {
-					res[x] = gamma[x]
+				res := make(map[heys.Block]float64)
+				for x := 0; x < size; x++ {
+					if gamma[x] > 0.0 {
+						res[heys.Block(x)] = gamma[x]
+					}
 				}
+				resp <- linearResponse{alpha, res}
 			}
-			resp <- linearResponse{alpha, res}
 
-		}(alph, responseChan)
+		}(alphaChan, responseChan)
 
 	}
 
-	result, mutex := make(map[int]map[int]float64), sync.Mutex{}
-	for i := 0; i < len(alphas); i++ {
-		response := <-responseChan
-		mutex.Lock()
-		result[response.alpha] = response.probability
-		mutex.Unlock()
+	for len(result) < len(opts.Alphas) {
+		select {
+		case response := <-responseChan:
+			saver.Lock()
+			result[response.alpha] = response.probability
+			state.Done[response.alpha] = response.probability
+			delete(state.Gamma, response.alpha)
+			saver.Unlock()
+		case <-saver.C:
+			if err := saver.Save(); err != nil {
+				return nil, err
+			}
+		case <-ctx.Done():
+			return nil, saver.Cancel(ctx)
+		}
+	}
+	if err := saver.Remove(); err != nil {
+		return nil, err
 	}
 
-	close(responseChan)
-
-	t2 := time.Now().Sub(t1)
-	fmt.Println("Runs", t2.Microseconds(), "ms")
-
-	return &result
+	return &result, nil
 }
 
-func approximate(alpha int) map[int]int {
-
-	result, scalars := make(map[int]int), make([]int, 16)
+// attackCheckpoint is state of Attack in checkpoint file: plaintexts, scores
+// of keys and index of the next approximation
+type attackCheckpoint struct {
+	Width          int             `json:"width"`
+	Count          int             `json:"count"`
+	SBox           []int           `json:"sbox"`
+	Permutation    []int           `json:"permutation"`
+	Approximations [][2]heys.Block `json:"approximations"`
+	Texts          []heys.Block    `json:"texts"`
+	Candidates     []int           `json:"candidates"`
+	Next           int             `json:"next"`
+}
 
-	for i := 0; i < 16; i++ {
-		с := 0
-		for j := 0; j < 16; j++ {
-			if ((i >> j) & 1) == 1 {
-				с++
+// loadAttackCheckpoint returns state of attack with opts and sorted
+// approximations, it is loaded from checkpoint file if opts.Resume is set and
+// the file exists, otherwise plaintexts are chosen randomly
+func loadAttackCheckpoint(opts AttackOptions, sorted []approximation) (*attackCheckpoint, error) {
+	spn, size := opts.Cipher.SPN(), opts.Cipher.Size()
+	state := &attackCheckpoint{
+		Width:          spn.Width(),
+		Count:          spn.Count(),
+		SBox:           opts.Cipher.SBox(),
+		Permutation:    spn.Permutation(),
+		Approximations: make([][2]heys.Block, len(sorted)),
+		Candidates:     make([]int, size),
+	}
+	for i, aprox := range sorted {
+		state.Approximations[i] = [2]heys.Block{aprox.alpha, aprox.beta}
+	}
+	if opts.Resume && opts.Checkpoint != "" {
+		saved := new(attackCheckpoint)
+		exist, err := checkpoint.Load(opts.Checkpoint, saved)
+		if err != nil {
+			return nil, err
+		}
+		if exist {
+			state.Texts, state.Candidates, state.Next = saved.Texts, saved.Candidates, saved.Next
+			if !reflect.DeepEqual(state, saved) || len(saved.Texts) != opts.Texts || len(saved.Candidates) != size {
+				return nil, fmt.Errorf("linear: checkpoint %s is saved by attack with other options", opts.Checkpoint)
 			}
+			return state, nil
 		}
-		scalars[i] = с & 1
 	}
+	texts := make(map[heys.Block]bool)
+	for len(texts) < opts.Texts {
+		texts[heys.Block(opts.intn(size))] = true
+	}
+	state.Texts = make([]heys.Block, 0, len(texts))
+	for block := range texts {
+		state.Texts = append(state.Texts, block)
+	}
+	sort.Slice(state.Texts, func(i, j int) bool {
+		return state.Texts[i] < state.Texts[j]
+	})
+	return state, nil
+}
 
-	linearApproximation := make([][]int, 16)
-	for a := 0; a < 16; a++ {
-		linearApproximation[a] = make([]int, 16)
-		for b := 0; b < 16; b++ {
-			linearApproximation[a][b] = 0
-			for x := 0; x < 16; x++ {
-				linearApproximation[a][b] += scalars[a&x] ^ scalars[b&heys.SBlocks[x]]
-			}
+// sparse returns nonnegative entries of gamma
+func sparse(gamma []float64) map[heys.Block]float64 {
+	res := make(map[heys.Block]float64)
+	for x := range gamma {
+		if gamma[x] > -1.0 {
+			res[heys.Block(x)] = gamma[x]
 		}
 	}
+	return res
+}
 
-	a0 := (alpha >> 0) & 0xf
-	a1 := (alpha >> 4) & 0xf
-	a2 := (alpha >> 8) & 0xf
-	a3 := (alpha >> 12) & 0xf
-
-	for beta := 0; beta < 0x10000; beta++ {
-
-		b0 := (beta >> 0) & 0xf
-		b1 := (beta >> 4) & 0xf
-		b2 := (beta >> 8) & 0xf
-		b3 := (beta >> 12) & 0xf
-
-		e0 := linearApproximation[a0][b0]
-		e1 := linearApproximation[a1][b1]
-		e2 := linearApproximation[a2][b2]
-		e3 := linearApproximation[a3][b3]
+// linearApproximationTable counts x with a·x != b·S(x) for all input masks a
+// and output masks b of S-box
+func linearApproximationTable(s *sbox.SBox) [][]int {
+	linearApproximation := s.LAT()
+	for a := range linearApproximation {
+		for b := range linearApproximation[a] {
+			linearApproximation[a][b] = s.Size()/2 - linearApproximation[a][b]
+		}
+	}
+	return linearApproximation
+}
 
-		z0 := 16 - e0
-		z1 := 16 - e1
-		z2 := 16 - e2
-		z3 := 16 - e3
+// approximate counts blocks x with alpha·x != beta·S(x) for round substitution
+// and returns them by permuted output mask beta
+func approximate(alpha int, spn *heys.SPN, linearApproximation [][]int) map[int]int {
 
-		num := 0
+	result, size, n := make(map[int]int), 1<<uint(spn.BlockSize()), len(linearApproximation)
 
-		num += e0 * z1 * z2 * z3
-		num += z0 * e1 * z2 * z3
-		num += z0 * z1 * e2 * z3
-		num += z0 * z1 * z2 * e3
+	for beta := 0; beta < size; beta++ {
 
-		num += z0 * e1 * e2 * e3
-		num += e0 * z1 * e2 * e3
-		num += e0 * e1 * z2 * e3
-		num += e0 * e1 * e2 * z3
+		// e is count of inputs with odd parity and z with even parity over
+		// S-boxes that has been combined
+		e, z := 0, 1
+		for i := 0; i < spn.Count(); i++ {
+			ei := linearApproximation[spn.Nibble(alpha, i)][spn.Nibble(beta, i)]
+			zi := n - ei
+			e, z = e*zi+z*ei, z*zi+e*ei
+		}
 
-		if num != 0x10000/2 {
-			result[heys.Permutation(beta)] = num
+		if e != size/2 {
+			result[spn.Permute(beta)] = e
 			// fmt.Println(fmt.Sprintf("0x%04x -- %d", b, probNum))
 		}
 	}
@@ -0,0 +1,136 @@
+package linear
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/mariiatuzovska/cryptanalysis/matsui"
+	"github.com/mariiatuzovska/cryptanalysis/sbox"
+)
+
+type (
+	// Trail is linear characteristic: Masks[i] is input mask of round i+1 and
+	// the last one is output mask, Active[i] is count of active S-boxes and
+	// Correlations[i] is correlation of round i+1, Correlation is their
+	// product by piling-up lemma
+	Trail struct {
+		Masks        []heys.Block `json:"masks"`
+		Active       []int        `json:"active"`
+		Correlations []float64    `json:"correlations"`
+		Correlation  float64      `json:"correlation"`
+	}
+	// Hull is potential of linear approximation estimated as sum of squared
+	// correlations of its trails that are not less than cutoff of SumTrails
+	Hull struct {
+		Potential float64 `json:"potential"`
+		// Trails is count of trails in Potential
+		Trails int `json:"trails"`
+		// Best is trail of approximation with the greatest squared correlation
+		Best Trail `json:"best"`
+	}
+)
+
+// Potential returns squared correlation of trail that is compared with
+// approximations of Search
+func (t Trail) Potential() float64 {
+	return t.Correlation * t.Correlation
+}
+
+// Bias returns bias of trail, it is a half of correlation
+func (t Trail) Bias() float64 {
+	return t.Correlation / 2
+}
+
+// BestTrail returns linear trail with the greatest squared correlation over
+// rounds of spn with sBox and weights of the best trails over 1, ...,
+// rounds rounds, weight is -log2 of squared correlation. The trail is found
+// by Matsui branch and bound search over S-box LAT
+func BestTrail(ctx context.Context, spn *heys.SPN, sBox []int, rounds int) (Trail, []float64, error) {
+	lat, table, err := transitions(spn, sBox)
+	if err != nil {
+		return Trail{}, nil, err
+	}
+	path, bounds, err := matsui.Search(ctx, spn, table, rounds)
+	if err != nil {
+		return Trail{}, nil, err
+	}
+	return pathTrail(spn, lat, path), bounds, nil
+}
+
+// SumTrails returns linear hull of approximation alpha, beta over rounds of
+// spn with sBox: all its trails with squared correlation not less than
+// cutoff are enumerated over S-box LAT. Search estimates the same sum for
+// all betas at once by pruning masks below thresholds after every round
+// instead of trails below cutoff
+func SumTrails(ctx context.Context, spn *heys.SPN, sBox []int, rounds int, alpha, beta heys.Block, cutoff float64) (Hull, error) {
+	if cutoff <= 0 || cutoff > 1 {
+		return Hull{}, fmt.Errorf("linear: invalid cutoff %v", cutoff)
+	}
+	lat, table, err := transitions(spn, sBox)
+	if err != nil {
+		return Hull{}, err
+	}
+	c, err := matsui.Sum(ctx, spn, table, rounds, int(alpha), int(beta), -math.Log2(cutoff))
+	if err != nil {
+		return Hull{}, err
+	}
+	hull := Hull{Potential: c.Sum, Trails: c.Paths}
+	if c.Paths != 0 {
+		hull.Best = pathTrail(spn, lat, c.Best)
+	}
+	return hull, nil
+}
+
+// transitions returns LAT of S-box and its transitions with weights -log2 of
+// their squared correlations
+func transitions(spn *heys.SPN, sBox []int) ([][]int, [][]matsui.Transition, error) {
+	s, err := sbox.New(sBox)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.Bits() != spn.Width() {
+		return nil, nil, fmt.Errorf("linear: %d-bit S-box for %d-bit S-boxes of SPN", s.Bits(), spn.Width())
+	}
+	lat, table := s.LAT(), make([][]matsui.Transition, s.Size())
+	for a, row := range lat {
+		for b := range row {
+			if c := correlation(lat, a, b); c != 0 {
+				table[a] = append(table[a], matsui.Transition{Output: b, Weight: -2 * math.Log2(math.Abs(c))})
+			}
+		}
+	}
+	return lat, table, nil
+}
+
+// correlation returns correlation of approximation a·x = b·S(x) of S-box
+// with lat
+func correlation(lat [][]int, a, b int) float64 {
+	return float64(2*lat[a][b]) / float64(len(lat))
+}
+
+// pathTrail returns trail of path of masks with correlations of S-box with
+// lat
+func pathTrail(spn *heys.SPN, lat [][]int, path matsui.Path) Trail {
+	rounds := len(path.Inputs)
+	t := Trail{
+		Masks:        []heys.Block{heys.Block(path.Inputs[0])},
+		Active:       make([]int, rounds),
+		Correlations: make([]float64, rounds),
+		Correlation:  1.0,
+	}
+	for r := range path.Outputs {
+		c := 1.0
+		for i := 0; i < spn.Count(); i++ {
+			if a := spn.Nibble(path.Inputs[r], i); a != 0 {
+				t.Active[r]++
+				c *= correlation(lat, a, spn.Nibble(path.Outputs[r], i))
+			}
+		}
+		t.Masks = append(t.Masks, heys.Block(spn.Permute(path.Outputs[r])))
+		t.Correlations[r] = c
+		t.Correlation *= c
+	}
+	return t
+}
@@ -0,0 +1,137 @@
+package linear
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/mariiatuzovska/cryptanalysis/sbox"
+)
+
+// small is 3-bit S-box which LAT has correlations ±1 and ±1/2
+var small = []int{1, 0, 2, 3, 4, 6, 5, 7}
+
+// permutation moves bit j of S-box i to bit j+1 of S-box i+j modulo 3, the
+// last bit of S-box is not kept in the last bit of any S-box
+var permutation = []int{1, 5, 6, 4, 8, 0, 7, 2, 3}
+
+// smallSPN returns SPN of three 3-bit S-boxes and LAT of small S-box
+func smallSPN(t *testing.T) (*heys.SPN, [][]int) {
+	t.Helper()
+	spn, err := heys.NewSPN(3, 3, 3, permutation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := sbox.New(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spn, s.LAT()
+}
+
+// enumerate calls visit with correlation of every trail over rounds of spn
+// from alpha by LAT and its output mask, nothing is pruned
+func enumerate(spn *heys.SPN, lat [][]int, rounds int, alpha heys.Block, visit func(c float64, beta heys.Block)) {
+	var substitute func(r, i, x, y int, c float64)
+	substitute = func(r, i, x, y int, c float64) {
+		if i == spn.Count() {
+			if r < rounds-1 {
+				substitute(r+1, 0, spn.Permute(y), 0, c)
+				return
+			}
+			visit(c, heys.Block(spn.Permute(y)))
+			return
+		}
+		a := spn.Nibble(x, i)
+		for b, n := range lat[a] {
+			if n != 0 {
+				substitute(r, i+1, x, y|b<<uint(i*spn.Width()), c*float64(2*n)/float64(len(lat)))
+			}
+		}
+	}
+	substitute(0, 0, int(alpha), 0, 1)
+}
+
+func TestBestTrail(t *testing.T) {
+	spn, lat := smallSPN(t)
+	rounds := spn.Rounds()
+	// best[r] is squared correlation of the best trail over r+1 rounds
+	best := make([]float64, rounds)
+	for r := range best {
+		for alpha := 1; alpha < 1<<uint(spn.BlockSize()); alpha++ {
+			enumerate(spn, lat, r+1, heys.Block(alpha), func(c float64, beta heys.Block) {
+				best[r] = math.Max(best[r], c*c)
+			})
+		}
+	}
+	trail, bounds, err := BestTrail(context.Background(), spn, small, rounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r := range bounds {
+		if math.Abs(math.Exp2(-bounds[r])-best[r]) > 1e-12 {
+			t.Fatalf("bound of %d rounds is 2^-%v, enumeration gives %v", r+1, bounds[r], best[r])
+		}
+	}
+	if len(trail.Masks) != rounds+1 || math.Abs(trail.Potential()-best[rounds-1]) > 1e-12 {
+		t.Fatalf("best trail %+v, enumeration gives squared correlation %v", trail, best[rounds-1])
+	}
+	// rounds of trail are LAT approximations of its masks with signs
+	want := 1.0
+	for r, c := range trail.Correlations {
+		x, y, rc := int(trail.Masks[r]), spn.InversePermute(int(trail.Masks[r+1])), 1.0
+		for i := 0; i < spn.Count(); i++ {
+			if a := spn.Nibble(x, i); a != 0 {
+				rc *= float64(2*lat[a][spn.Nibble(y, i)]) / float64(len(lat))
+			}
+		}
+		if c != rc {
+			t.Fatalf("round %d of trail %+v has correlation %v, LAT gives %v", r+1, trail, c, rc)
+		}
+		want *= rc
+	}
+	if trail.Correlation != want || trail.Bias() != want/2 {
+		t.Fatalf("trail %+v has correlation %v, rounds give %v", trail, trail.Correlation, want)
+	}
+	if _, _, err := BestTrail(context.Background(), spn, heys.SBlocks, rounds); err == nil {
+		t.Error("4-bit S-box is accepted for 3-bit S-boxes")
+	}
+}
+
+func TestSumTrails(t *testing.T) {
+	spn, lat := smallSPN(t)
+	rounds := spn.Rounds()
+	for _, alpha := range spn.SingleSBoxBlocks() {
+		// all trails are above the first cutoff, the second cuts some
+		for _, cutoff := range []float64{1e-9, 1.0 / 16} {
+			want, count, best := make(map[heys.Block]float64), make(map[heys.Block]int), make(map[heys.Block]float64)
+			enumerate(spn, lat, rounds, alpha, func(c float64, beta heys.Block) {
+				if c*c >= cutoff {
+					want[beta] += c * c
+					count[beta]++
+					best[beta] = math.Max(best[beta], c*c)
+				}
+			})
+			total := 0.0
+			for beta := heys.Block(1); int(beta) < 1<<uint(spn.BlockSize()); beta++ {
+				hull, err := SumTrails(context.Background(), spn, small, rounds, alpha, beta, cutoff)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if hull.Trails != count[beta] || math.Abs(hull.Potential-want[beta]) > 1e-12 || hull.Best.Potential() != best[beta] {
+					t.Fatalf("cutoff %v: hull of 0x%03x, 0x%03x is %+v, enumeration gives %d trails of %v, the best %v",
+						cutoff, alpha, beta, hull, count[beta], want[beta], best[beta])
+				}
+				total += hull.Potential
+			}
+			// potentials of approximations of alpha sum to one by Parseval
+			if cutoff < 1e-6 && math.Abs(total-1) > 1e-9 {
+				t.Fatalf("potentials of 0x%03x sum to %v", alpha, total)
+			}
+		}
+	}
+	if _, err := SumTrails(context.Background(), spn, small, rounds, 1, 1, 0); err == nil {
+		t.Error("zero cutoff is accepted")
+	}
+}
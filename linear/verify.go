@@ -0,0 +1,130 @@
+package linear
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/mariiatuzovska/cryptanalysis/progress"
+	"github.com/mariiatuzovska/cryptanalysis/sbox"
+	"github.com/mariiatuzovska/cryptanalysis/stats"
+)
+
+type (
+	// VerifyOptions are parameters of Verify, zero fields take default values
+	VerifyOptions struct {
+		// Cipher which S-box and SPN are used with random keys,
+		// heys.DefaultCipher() if nil
+		Cipher *heys.Cipher
+		// Rounds is count of rounds of approximation, rounds of cipher minus
+		// the last one if zero
+		Rounds int
+		// Keys is count of random keys, at least 2, stats.Keys if zero
+		Keys int
+		// Texts is count of random plaintexts of every key, all plaintexts if
+		// zero
+		Texts int
+		// Rand chooses keys and plaintexts, seeded by math/rand package if nil
+		Rand *rand.Rand
+		// Progress receives count of keys done if not nil
+		Progress progress.Func
+	}
+	// Verification is empirical squared correlation of approximation
+	// averaged over random keys, sign of correlation depends on key
+	Verification struct {
+		// Potential is mean of squared correlations of keys
+		Potential float64 `json:"potential"`
+		// Low and High are bounds of 95% confidence interval of Potential
+		Low  float64 `json:"low"`
+		High float64 `json:"high"`
+		// Bias is mean of absolute biases of keys
+		Bias float64 `json:"bias"`
+		// Min and Max are the least and the greatest squared correlations of
+		// keys
+		Min  float64 `json:"min"`
+		Max  float64 `json:"max"`
+		Keys int     `json:"keys"`
+		// Texts is count of plaintexts of every key
+		Texts int `json:"texts"`
+	}
+)
+
+// withDefaults returns copy of options with default values of zero fields
+func (opts VerifyOptions) withDefaults() (VerifyOptions, error) {
+	if opts.Cipher == nil {
+		opts.Cipher = heys.DefaultCipher()
+	}
+	if opts.Rounds == 0 {
+		opts.Rounds = opts.Cipher.Rounds() - 1
+	}
+	if opts.Keys == 0 {
+		opts.Keys = stats.Keys
+	}
+	if opts.Rand == nil {
+		opts.Rand = rand.New(rand.NewSource(rand.Int63()))
+	}
+	if opts.Rounds < 1 || opts.Rounds > opts.Cipher.Rounds() {
+		return opts, fmt.Errorf("linear: invalid count of rounds %d of %d-round cipher", opts.Rounds, opts.Cipher.Rounds())
+	}
+	if opts.Keys < 2 {
+		return opts, fmt.Errorf("linear: invalid count of keys %d, confidence interval needs at least 2", opts.Keys)
+	}
+	if opts.Texts < 0 || opts.Texts == 1 {
+		return opts, fmt.Errorf("linear: invalid count of texts %d", opts.Texts)
+	}
+	return opts, nil
+}
+
+// Verify measures squared correlation of approximation alpha, beta over
+// opts.Rounds rounds of cipher with random keys: for every key it counts
+// plaintexts x with alpha·x = beta·y where y is output of
+// heys.Cipher.EncryptRounds. Squared correlation of random plaintexts is
+// corrected by (n·c²-1)/(n-1) as it is increased by about 1/n by sampling
+func Verify(ctx context.Context, alpha, beta heys.Block, opts VerifyOptions) (Verification, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return Verification{}, err
+	}
+	size, spn := opts.Cipher.Size(), opts.Cipher.SPN()
+	if alpha == 0 || beta == 0 || int(alpha) >= size || int(beta) >= size {
+		return Verification{}, fmt.Errorf("linear: invalid approximation 0x%04x -- 0x%04x of %d-bit block", alpha, beta, spn.BlockSize())
+	}
+	texts := opts.Texts
+	if texts == 0 {
+		texts = size
+	}
+	v := Verification{Min: 1.0, Keys: opts.Keys, Texts: texts}
+	tracker := progress.NewTracker(opts.Progress, "verify", opts.Keys)
+	potentials := make([]float64, opts.Keys)
+	for k := range potentials {
+		if ctx.Err() != nil {
+			return Verification{}, ctx.Err()
+		}
+		cipher, err := heys.NewSPNCipher(spn, heys.RandomSPNKeys(opts.Rand, spn.BlockSize(), spn.Rounds()), opts.Cipher.SBox())
+		if err != nil {
+			return Verification{}, err
+		}
+		count := 0
+		for i := 0; i < texts; i++ {
+			x := heys.Block(i)
+			if opts.Texts != 0 {
+				x = heys.Block(opts.Rand.Intn(size))
+			}
+			if sbox.Parity(int(alpha&x)) == sbox.Parity(int(beta&cipher.EncryptRounds(x, opts.Rounds))) {
+				count++
+			}
+		}
+		c := 2*float64(count)/float64(texts) - 1
+		v.Bias += math.Abs(c) / 2 / float64(opts.Keys)
+		potentials[k] = c * c
+		if opts.Texts != 0 {
+			potentials[k] = (float64(texts)*c*c - 1) / float64(texts-1)
+		}
+		v.Min, v.Max = math.Min(v.Min, potentials[k]), math.Max(v.Max, potentials[k])
+		tracker.Add(0, 0, 1)
+	}
+	v.Potential, v.Low, v.High = stats.MeanInterval(potentials)
+	return v, nil
+}
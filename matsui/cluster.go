@@ -0,0 +1,128 @@
+package matsui
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+)
+
+// Cluster is sum of 2^-weight over all paths from one input to one output
+// with weight not greater than cutoff: probability of differential or
+// potential of linear hull estimated by its trails
+type Cluster struct {
+	Sum float64
+	// Paths is count of paths in Sum
+	Paths int
+	// Best is the lightest path, it is empty if there are no paths
+	Best Path
+}
+
+// cluster is state of enumeration of paths of Cluster
+type cluster struct {
+	*search
+	// weights[a][b] is weight of transition a to b, +Inf if it is impossible
+	weights [][]float64
+	// output is output of substitution of the last round
+	output int
+	cutoff float64
+	result Cluster
+}
+
+// Sum returns Cluster of paths over rounds of spn from input to output with
+// weight not greater than cutoff, table[a] are transitions of S-box input a.
+// Paths are enumerated depth-first and a path is pruned when its weight after
+// i rounds plus weight of the best path over r-i rounds exceeds cutoff
+func Sum(ctx context.Context, spn *heys.SPN, table [][]Transition, rounds, input, output int, cutoff float64) (Cluster, error) {
+	if rounds < 1 {
+		return Cluster{}, fmt.Errorf("matsui: invalid count of rounds %d", rounds)
+	}
+	if input == 0 || output == 0 {
+		return Cluster{}, fmt.Errorf("matsui: zero input or output")
+	}
+	s, err := newSearch(ctx, spn, table)
+	if err != nil {
+		return Cluster{}, err
+	}
+	if err = s.run(rounds - 1); err != nil {
+		return Cluster{}, err
+	}
+	c := &cluster{
+		search:  s,
+		weights: make([][]float64, len(s.table)),
+		output:  spn.InversePermute(output),
+		cutoff:  cutoff + epsilon,
+	}
+	for a := range c.weights {
+		c.weights[a] = make([]float64, len(s.table))
+		for b := range c.weights[a] {
+			c.weights[a][b] = math.Inf(1)
+		}
+		for _, t := range s.table[a] {
+			c.weights[a][t.Output] = t.Weight
+		}
+	}
+	c.weights[0][0] = 0
+	c.rounds, c.current = rounds, newPath(rounds)
+	c.substitute(0, 0, input, 0, 0, 0)
+	if c.err != nil {
+		return Cluster{}, c.err
+	}
+	return c.result, nil
+}
+
+// substitute chooses transition of S-box i in round r, x and y are input and
+// output of round chosen so far, w is weight of path and rw is weight of
+// round so far
+func (c *cluster) substitute(r, i, x, y int, w, rw float64) {
+	if c.err != nil {
+		return
+	}
+	if c.nodes++; c.nodes&0xffff == 0 && c.ctx.Err() != nil {
+		c.err = c.ctx.Err()
+		return
+	}
+	if i == c.spn.Count() {
+		c.current.Inputs[r], c.current.Outputs[r], c.current.Weights[r] = x, y, rw
+		if r < c.rounds-1 {
+			c.substitute(r+1, 0, c.spn.Permute(y), 0, w, 0)
+			return
+		}
+		if c.result.Paths == 0 || w < c.result.Best.Weight() {
+			c.result.Best = newPath(c.rounds)
+			copy(c.result.Best.Inputs, c.current.Inputs)
+			copy(c.result.Best.Outputs, c.current.Outputs)
+			copy(c.result.Best.Weights, c.current.Weights)
+		}
+		c.result.Sum += math.Exp2(-w)
+		c.result.Paths++
+		return
+	}
+	shift, a := uint(i*c.spn.Width()), c.spn.Nibble(x, i)
+	remaining := c.bounds[c.rounds-r-1]
+	for j := i + 1; j < c.spn.Count(); j++ {
+		if c.spn.Nibble(x, j) != 0 {
+			remaining += c.minWeight
+		}
+	}
+	if r == c.rounds-1 {
+		// output of the last round is fixed, impossible transition is not
+		// taken even by infinite cutoff
+		b := c.spn.Nibble(c.output, i)
+		if t := c.weights[a][b]; !math.IsInf(t, 1) && w+t+remaining <= c.cutoff {
+			c.substitute(r, i+1, x, y|b<<shift, w+t, rw+t)
+		}
+		return
+	}
+	if a == 0 {
+		c.substitute(r, i+1, x, y, w, rw)
+		return
+	}
+	for _, t := range c.table[a] {
+		if w+t.Weight+remaining > c.cutoff {
+			break
+		}
+		c.substitute(r, i+1, x, y|t.Output<<shift, w+t.Weight, rw+t.Weight)
+	}
+}
@@ -0,0 +1,60 @@
+package matsui
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestSum(t *testing.T) {
+	spn, table := smallSPN(t), ddtTable(t)
+	for rounds := 1; rounds <= spn.Rounds(); rounds++ {
+		for _, input := range spn.SingleSBoxBlocks() {
+			for _, cutoff := range []float64{math.Inf(1), float64(rounds + 1)} {
+				// enumeration of paths from input by their outputs
+				want := make(map[int]*Cluster)
+				enumerate(spn, table, rounds, int(input), func(p Path, output int) {
+					if p.Weight() > cutoff {
+						return
+					}
+					c, ok := want[output]
+					if !ok {
+						c = new(Cluster)
+						want[output] = c
+					}
+					c.Sum += math.Exp2(-p.Weight())
+					if c.Paths++; c.Paths == 1 || p.Weight() < c.Best.Weight() {
+						c.Best = Path{append([]int{}, p.Inputs...), append([]int{}, p.Outputs...), append([]float64{}, p.Weights...)}
+					}
+				})
+				for output := 1; output < 1<<uint(spn.BlockSize()); output++ {
+					c, err := Sum(context.Background(), spn, table, rounds, int(input), output, cutoff)
+					if err != nil {
+						t.Fatal(err)
+					}
+					w, ok := want[output]
+					if !ok {
+						w = new(Cluster)
+					}
+					if c.Paths != w.Paths || math.Abs(c.Sum-w.Sum) > 1e-12 {
+						t.Fatalf("%d rounds, cutoff %v: %d paths from 0x%03x to 0x%03x sum to %v, enumeration gives %d paths of %v",
+							rounds, cutoff, c.Paths, input, output, c.Sum, w.Paths, w.Sum)
+					}
+					if c.Paths == 0 {
+						continue
+					}
+					checkPath(t, spn, table, c.Best, rounds)
+					if c.Best.Inputs[0] != int(input) || spn.Permute(c.Best.Outputs[rounds-1]) != output || math.Abs(c.Best.Weight()-w.Best.Weight()) > epsilon {
+						t.Fatalf("%d rounds: the best path from 0x%03x to 0x%03x is %v, enumeration gives %v", rounds, input, output, c.Best, w.Best)
+					}
+				}
+			}
+		}
+	}
+	if _, err := Sum(context.Background(), spn, table, 2, 0, 1, 10); err == nil {
+		t.Error("paths from zero input are summed")
+	}
+	if _, err := Sum(context.Background(), spn, table, 0, 1, 1, 10); err == nil {
+		t.Error("zero rounds are summed")
+	}
+}
@@ -0,0 +1,212 @@
+package matsui
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+)
+
+// epsilon is tolerance of comparison of trail weights
+const epsilon = 1e-9
+
+type (
+	// Transition is output of S-box with Weight that is -log2 of probability
+	// of differential or squared correlation of linear approximation
+	Transition struct {
+		Output int
+		Weight float64
+	}
+	// Path is trail of rounds: Inputs[i] and Outputs[i] are input and output
+	// of substitution of round i+1, output is permuted before the next round,
+	// Weights[i] is weight of round i+1
+	Path struct {
+		Inputs  []int
+		Outputs []int
+		Weights []float64
+	}
+	// search is state of branch and bound search of the best path
+	search struct {
+		ctx    context.Context
+		spn    *heys.SPN
+		rounds int
+		// table[a] are transitions of S-box input a sorted by weight
+		table [][]Transition
+		// minWeight is weight of the lightest transition of active S-box
+		minWeight float64
+		// bounds[i] is weight of the best path over i rounds
+		bounds []float64
+		// best is weight of the best path found so far plus epsilon, only
+		// lighter paths are searched
+		best          float64
+		current, path Path
+		nodes         int
+		err           error
+	}
+)
+
+// Weight returns sum of weights of rounds of path
+func (p Path) Weight() float64 {
+	w := 0.0
+	for _, rw := range p.Weights {
+		w += rw
+	}
+	return w
+}
+
+// Search returns the lightest path over rounds of spn and weights of the
+// lightest paths over 1, ..., rounds rounds, table[a] are transitions of
+// S-box input a, zero input has only zero output. It is Matsui branch and
+// bound search: the best paths are searched for 1, 2, ... rounds, the first
+// estimation for r rounds is the best path over r-1 rounds extended by the
+// lightest transitions of its active S-boxes and a path is pruned when its
+// weight after i rounds plus weight of the best path over r-i rounds is not
+// less than weight of the best path of r rounds found so far
+func Search(ctx context.Context, spn *heys.SPN, table [][]Transition, rounds int) (Path, []float64, error) {
+	if rounds < 1 {
+		return Path{}, nil, fmt.Errorf("matsui: invalid count of rounds %d", rounds)
+	}
+	s, err := newSearch(ctx, spn, table)
+	if err != nil {
+		return Path{}, nil, err
+	}
+	if err = s.run(rounds); err != nil {
+		return Path{}, nil, err
+	}
+	return s.path, s.bounds[1:], nil
+}
+
+// newSearch returns search with table sorted by weight
+func newSearch(ctx context.Context, spn *heys.SPN, table [][]Transition) (*search, error) {
+	if len(table) != 1<<uint(spn.Width()) {
+		return nil, fmt.Errorf("matsui: table of %d inputs for %d-bit S-boxes", len(table), spn.Width())
+	}
+	s := &search{
+		ctx:       ctx,
+		spn:       spn,
+		table:     make([][]Transition, len(table)),
+		minWeight: math.Inf(1),
+		bounds:    []float64{0},
+	}
+	for a := 1; a < len(table); a++ {
+		if len(table[a]) == 0 {
+			return nil, fmt.Errorf("matsui: S-box input %d has no transitions", a)
+		}
+		s.table[a] = append([]Transition{}, table[a]...)
+		sort.SliceStable(s.table[a], func(i, j int) bool {
+			return s.table[a][i].Weight < s.table[a][j].Weight
+		})
+		if s.table[a][0].Weight < s.minWeight {
+			s.minWeight = s.table[a][0].Weight
+		}
+	}
+	return s, nil
+}
+
+// run searches the best paths over 1, ..., rounds rounds
+func (s *search) run(rounds int) error {
+	for r := 1; r <= rounds; r++ {
+		s.rounds = r
+		s.start()
+		s.current = newPath(r)
+		s.substitute(0, 0, 0, 0, 0, 0)
+		if s.err != nil {
+			return s.err
+		}
+		s.bounds = append(s.bounds, s.best-epsilon)
+	}
+	return nil
+}
+
+// newPath returns path of rounds with zero inputs and outputs
+func newPath(rounds int) Path {
+	return Path{make([]int, rounds), make([]int, rounds), make([]float64, rounds)}
+}
+
+// start sets the first estimation of the best path over s.rounds rounds
+func (s *search) start() {
+	p := newPath(s.rounds)
+	copy(p.Inputs, s.path.Inputs)
+	copy(p.Outputs, s.path.Outputs)
+	copy(p.Weights, s.path.Weights)
+	x, y, w := 0, 0, 0.0
+	if s.rounds == 1 {
+		for a := 1; a < len(s.table); a++ {
+			if s.table[a][0].Weight == s.minWeight {
+				x, y, w = a, s.table[a][0].Output, s.minWeight
+				break
+			}
+		}
+	} else {
+		x = s.spn.Permute(p.Outputs[s.rounds-2])
+		for i := 0; i < s.spn.Count(); i++ {
+			if a := s.spn.Nibble(x, i); a != 0 {
+				y |= s.table[a][0].Output << uint(i*s.spn.Width())
+				w += s.table[a][0].Weight
+			}
+		}
+	}
+	p.Inputs[s.rounds-1], p.Outputs[s.rounds-1], p.Weights[s.rounds-1] = x, y, w
+	s.path, s.best = p, p.Weight()+epsilon
+}
+
+// substitute chooses transition of S-box i in round r, x and y are input and
+// output of round chosen so far, w is weight of path and rw is weight of
+// round so far
+func (s *search) substitute(r, i, x, y int, w, rw float64) {
+	if s.err != nil {
+		return
+	}
+	if s.nodes++; s.nodes&0xffff == 0 && s.ctx.Err() != nil {
+		s.err = s.ctx.Err()
+		return
+	}
+	if i == s.spn.Count() {
+		if x == 0 {
+			return
+		}
+		s.current.Inputs[r], s.current.Outputs[r], s.current.Weights[r] = x, y, rw
+		if r < s.rounds-1 {
+			s.substitute(r+1, 0, s.spn.Permute(y), 0, w, 0)
+			return
+		}
+		s.best = w + epsilon
+		copy(s.path.Inputs, s.current.Inputs)
+		copy(s.path.Outputs, s.current.Outputs)
+		copy(s.path.Weights, s.current.Weights)
+		return
+	}
+	shift, remaining := uint(i*s.spn.Width()), s.bounds[s.rounds-r-1]
+	if r > 0 {
+		a := s.spn.Nibble(x, i)
+		if a == 0 {
+			s.substitute(r, i+1, x, y, w, rw)
+			return
+		}
+		// every next active S-box of round adds at least minWeight
+		for j := i + 1; j < s.spn.Count(); j++ {
+			if s.spn.Nibble(x, j) != 0 {
+				remaining += s.minWeight
+			}
+		}
+		for _, t := range s.table[a] {
+			if w+t.Weight+remaining >= s.best {
+				break
+			}
+			s.substitute(r, i+1, x, y|t.Output<<shift, w+t.Weight, rw+t.Weight)
+		}
+		return
+	}
+	// input of the first round is chosen S-box by S-box
+	s.substitute(r, i+1, x, y, w, rw)
+	for a := 1; a < len(s.table); a++ {
+		for _, t := range s.table[a] {
+			if w+t.Weight+remaining >= s.best {
+				break
+			}
+			s.substitute(r, i+1, x|a<<shift, y|t.Output<<shift, w+t.Weight, rw+t.Weight)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package matsui
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/mariiatuzovska/cryptanalysis/sbox"
+)
+
+// small is 3-bit S-box which DDT has transitions of probabilities 1/2 and
+// 1/4, so trails of the same active S-boxes have different weights
+var small = []int{1, 0, 2, 3, 4, 6, 5, 7}
+
+// permutation moves bit j of S-box i to bit j+1 of S-box i+j modulo 3, the
+// last bit of S-box is not kept in the last bit of any S-box
+var permutation = []int{1, 5, 6, 4, 8, 0, 7, 2, 3}
+
+// smallSPN returns SPN of three 3-bit S-boxes
+func smallSPN(t *testing.T) *heys.SPN {
+	t.Helper()
+	spn, err := heys.NewSPN(3, 3, 3, permutation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spn
+}
+
+// ddtTable returns transitions of DDT of small S-box
+func ddtTable(t *testing.T) [][]Transition {
+	t.Helper()
+	s, err := sbox.New(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := make([][]Transition, s.Size())
+	for a, row := range s.DDT() {
+		for b, n := range row {
+			if n != 0 {
+				table[a] = append(table[a], Transition{Output: b, Weight: math.Log2(float64(s.Size()) / float64(n))})
+			}
+		}
+	}
+	return table
+}
+
+// enumerate calls visit with every path over rounds of spn from input by
+// transitions of table and its output after permutation, nothing is pruned
+func enumerate(spn *heys.SPN, table [][]Transition, rounds, input int, visit func(p Path, output int)) {
+	p := newPath(rounds)
+	var substitute func(r, i, x, y int, rw float64)
+	substitute = func(r, i, x, y int, rw float64) {
+		if i == spn.Count() {
+			p.Inputs[r], p.Outputs[r], p.Weights[r] = x, y, rw
+			if r < rounds-1 {
+				substitute(r+1, 0, spn.Permute(y), 0, 0)
+				return
+			}
+			visit(p, spn.Permute(y))
+			return
+		}
+		a := spn.Nibble(x, i)
+		if a == 0 {
+			substitute(r, i+1, x, y, rw)
+			return
+		}
+		for _, t := range table[a] {
+			substitute(r, i+1, x, y|t.Output<<uint(i*spn.Width()), rw+t.Weight)
+		}
+	}
+	substitute(0, 0, input, 0, 0)
+}
+
+// checkPath checks that path over rounds of spn is made of transitions of
+// table with their weights
+func checkPath(t *testing.T, spn *heys.SPN, table [][]Transition, p Path, rounds int) {
+	t.Helper()
+	if len(p.Inputs) != rounds || len(p.Outputs) != rounds || len(p.Weights) != rounds || p.Inputs[0] == 0 {
+		t.Fatalf("%d-round path is %v", rounds, p)
+	}
+	for r := range p.Inputs {
+		if r > 0 && p.Inputs[r] != spn.Permute(p.Outputs[r-1]) {
+			t.Fatalf("input of round %d of path %v is not permuted output", r+1, p)
+		}
+		w := 0.0
+		for i := 0; i < spn.Count(); i++ {
+			a, b, found := spn.Nibble(p.Inputs[r], i), spn.Nibble(p.Outputs[r], i), false
+			if a == 0 && b == 0 {
+				continue
+			}
+			for _, tr := range table[a] {
+				if tr.Output == b {
+					w, found = w+tr.Weight, true
+				}
+			}
+			if !found {
+				t.Fatalf("S-box %d of round %d of path %v has no transition %d to %d", i, r+1, p, a, b)
+			}
+		}
+		if math.Abs(w-p.Weights[r]) > epsilon {
+			t.Fatalf("round %d of path %v weighs %v", r+1, p, w)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	spn, table, rounds := smallSPN(t), ddtTable(t), 3
+	// best[r] is weight of the lightest path over r+1 rounds of all paths
+	best := make([]float64, rounds)
+	for r := range best {
+		best[r] = math.Inf(1)
+		for input := 1; input < 1<<uint(spn.BlockSize()); input++ {
+			enumerate(spn, table, r+1, input, func(p Path, output int) {
+				best[r] = math.Min(best[r], p.Weight())
+			})
+		}
+	}
+	for r := 1; r <= rounds; r++ {
+		path, bounds, err := Search(context.Background(), spn, table, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bounds) != r {
+			t.Fatalf("%d rounds: %d bounds", r, len(bounds))
+		}
+		for i := range bounds {
+			if math.Abs(bounds[i]-best[i]) > epsilon {
+				t.Fatalf("%d rounds: bound of %d rounds is %v, enumeration gives %v", r, i+1, bounds[i], best[i])
+			}
+		}
+		checkPath(t, spn, table, path, r)
+		if math.Abs(path.Weight()-best[r-1]) > epsilon {
+			t.Fatalf("best path over %d rounds weighs %v, enumeration gives %v", r, path.Weight(), best[r-1])
+		}
+	}
+	if _, _, err := Search(context.Background(), spn, table, 0); err == nil {
+		t.Error("zero rounds are searched")
+	}
+	if _, _, err := Search(context.Background(), spn, table[:4], 1); err == nil {
+		t.Error("table of 2-bit S-box is accepted for 3-bit S-boxes")
+	}
+}
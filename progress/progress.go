@@ -0,0 +1,150 @@
+package progress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+)
+
+// interval is min time between reports of Tracker
+const interval = 100 * time.Millisecond
+
+// Progress is state of a long search or attack, Done and Total count units of
+// work: rounds of alphas for searches and tested keys for attacks
+type Progress struct {
+	Stage       string
+	Done, Total int
+	// Rounds is count of rounds done over all alphas
+	Rounds int
+	// Alphas is count of alphas done
+	Alphas int
+	// Keys is count of keys tested
+	Keys int
+	// Resumed is count of units done before start, they are not counted in ETA
+	Resumed int
+	Elapsed time.Duration
+}
+
+// Func receives progress reports, it is never called concurrently
+type Func func(Progress)
+
+// ETA returns estimated time until work is done
+func (p Progress) ETA() time.Duration {
+	if p.Done <= p.Resumed {
+		return 0
+	}
+	return time.Duration(float64(p.Elapsed) * float64(p.Total-p.Done) / float64(p.Done-p.Resumed))
+}
+
+// Tracker counts work done by concurrent goroutines and reports it to Func no
+// more often than every 100 ms and when work is done
+type Tracker struct {
+	mutex    sync.Mutex
+	f        Func
+	start    time.Time
+	reported time.Time
+	progress Progress
+}
+
+// NewTracker returns tracker of total units of work of stage, nil f means no
+// reports
+func NewTracker(f Func, stage string, total int) *Tracker {
+	return &Tracker{
+		f:        f,
+		start:    time.Now(),
+		progress: Progress{Stage: stage, Total: total},
+	}
+}
+
+// Resume adds rounds and alphas done and tested keys before start without
+// report
+func (t *Tracker) Resume(rounds, alphas, keys int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.progress.Rounds += rounds
+	t.progress.Alphas += alphas
+	t.progress.Keys += keys
+	t.progress.Done += rounds + keys
+	t.progress.Resumed += rounds + keys
+}
+
+// Add adds rounds and alphas done and tested keys, rounds and keys are
+// counted as units of work
+func (t *Tracker) Add(rounds, alphas, keys int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.progress.Rounds += rounds
+	t.progress.Alphas += alphas
+	t.progress.Keys += keys
+	t.progress.Done += rounds + keys
+	if t.f == nil {
+		return
+	}
+	now := time.Now()
+	if now.Sub(t.reported) < interval && t.progress.Done < t.progress.Total {
+		return
+	}
+	t.reported = now
+	p := t.progress
+	p.Elapsed = now.Sub(t.start)
+	t.f(p)
+}
+
+// Progress returns current progress
+func (t *Tracker) Progress() Progress {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	p := t.progress
+	p.Elapsed = time.Since(t.start)
+	return p
+}
+
+// Bar returns Func that draws progress bar of width characters to w, the bar
+// is redrawn in place and ends with new line when work is done
+func Bar(w io.Writer, width int) Func {
+	return func(p Progress) {
+		filled := width
+		if p.Total > 0 {
+			filled = width * p.Done / p.Total
+		}
+		percent := 100
+		if p.Total > 0 {
+			percent = 100 * p.Done / p.Total
+		}
+		line := fmt.Sprintf("\r%s [%s%s] %3d%% %d/%d", p.Stage, strings.Repeat("=", filled), strings.Repeat(" ", width-filled), percent, p.Done, p.Total)
+		if p.Alphas != 0 || p.Rounds != 0 {
+			line += fmt.Sprintf(" alphas %d rounds %d", p.Alphas, p.Rounds)
+		}
+		if p.Keys != 0 {
+			line += fmt.Sprintf(" keys %d", p.Keys)
+		}
+		line += fmt.Sprintf(" ETA %s ", p.ETA().Round(time.Second))
+		if p.Done >= p.Total {
+			line += "\n"
+		}
+		io.WriteString(w, line)
+	}
+}
+
+// InterruptContext returns context that is cancelled on interrupt signal,
+// the second interrupt is not caught and terminates program
+func InterruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		select {
+		case <-signals:
+			signal.Stop(signals)
+			cancel()
+		case <-ctx.Done():
+			signal.Stop(signals)
+		}
+	}()
+	return ctx, cancel
+}
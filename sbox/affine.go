@@ -0,0 +1,198 @@
+package sbox
+
+import (
+	"fmt"
+	"sync"
+)
+
+// OptimalClasses are representatives G0, ..., G15 of 16 affine equivalence
+// classes of optimal 4-bit S-boxes by Leander and Poschmann, an S-box is
+// optimal if its differential uniformity is 4 and linearity is 8
+var OptimalClasses = [][]int{
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 11, 12, 9, 3, 14, 10, 5},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 11, 14, 3, 5, 9, 10, 12},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 11, 14, 3, 10, 12, 5, 9},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 12, 5, 3, 10, 14, 11, 9},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 12, 9, 11, 10, 14, 5, 3},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 12, 11, 9, 10, 14, 3, 5},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 12, 11, 9, 10, 14, 5, 3},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 12, 14, 11, 10, 9, 3, 5},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 14, 9, 5, 10, 11, 3, 12},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 14, 11, 3, 5, 9, 10, 12},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 14, 11, 5, 10, 9, 3, 12},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 14, 11, 10, 5, 9, 12, 3},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 14, 11, 10, 9, 3, 12, 5},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 14, 12, 9, 5, 11, 10, 3},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 14, 12, 11, 3, 9, 5, 10},
+	{0, 1, 2, 13, 4, 7, 15, 6, 8, 14, 12, 11, 9, 3, 10, 5},
+}
+
+// PRESENT is S-box of PRESENT block cipher
+var PRESENT = []int{0xc, 0x5, 0x6, 0xb, 0x9, 0x0, 0xa, 0xd, 0x3, 0xe, 0xf, 0x8, 0x4, 0x7, 0x1, 0x2}
+
+// Serpent are S-boxes S0, ..., S7 of Serpent block cipher
+var Serpent = [][]int{
+	{3, 8, 15, 1, 10, 6, 5, 11, 14, 13, 4, 2, 7, 0, 9, 12},
+	{15, 12, 2, 7, 9, 0, 5, 10, 1, 11, 14, 8, 6, 13, 3, 4},
+	{8, 6, 7, 9, 3, 12, 10, 15, 13, 1, 14, 4, 0, 11, 5, 2},
+	{0, 15, 11, 8, 12, 9, 6, 3, 13, 1, 2, 4, 10, 7, 5, 14},
+	{1, 15, 8, 3, 12, 0, 11, 6, 2, 5, 4, 10, 9, 14, 7, 13},
+	{15, 5, 2, 11, 4, 10, 9, 12, 0, 3, 14, 8, 13, 6, 7, 1},
+	{7, 2, 12, 5, 8, 4, 6, 11, 14, 9, 1, 15, 13, 3, 10, 0},
+	{1, 13, 15, 0, 14, 8, 2, 11, 7, 4, 12, 10, 9, 3, 5, 6},
+}
+
+var (
+	optimalOnce            sync.Once
+	optimalRepresentatives [][]int
+)
+
+// AffineRepresentative returns lexicographically smallest S-box B∘S∘A over
+// affine permutations A and B, it is the same for all S-boxes of affine
+// equivalence class, S-box must have at most 4 bits. Representatives of
+// 4-bit S-boxes are numbered among 302 classes by AffineClass
+func (s *SBox) AffineRepresentative() (*SBox, error) {
+	if s.bits > 4 {
+		return nil, fmt.Errorf("sbox: affine representative of %d-bit S-box is not supported", s.bits)
+	}
+	best := make([]int, s.Size())
+	for i := range best {
+		best[i] = s.Size()
+	}
+	t, image := make([]int, s.Size()), make([]int, s.Size())
+	forEachLinear(s.bits, func(m []int) {
+		for c := 0; c < s.Size(); c++ {
+			for x := range t {
+				t[x] = s.values[m[x]^c]
+			}
+			s.minimizeOutput(t, image, best)
+		}
+	})
+	return New(best)
+}
+
+// minimizeOutput replaces best by the smallest L(t(x)^t(0)) over linear
+// permutations L if it is less than best, L is chosen greedily: image of new
+// linearly independent value is the smallest value out of span of images
+func (s *SBox) minimizeOutput(t, image, best []int) {
+	for i := range image {
+		image[i] = -1
+	}
+	image[0] = 0
+	span, less := 1, false
+	for x := range t {
+		y := t[x] ^ t[0]
+		if image[y] == -1 {
+			for z := 0; z < s.Size(); z++ {
+				if image[z] != -1 && image[z] < span {
+					image[z^y] = image[z] ^ span
+				}
+			}
+			span <<= 1
+		}
+		if !less {
+			if image[y] > best[x] {
+				return
+			}
+			less = image[y] < best[x]
+		}
+		if less {
+			best[x] = image[y]
+		}
+	}
+}
+
+// forEachLinear calls f with table of every invertible linear map of n bits
+func forEachLinear(n int, f func(m []int)) {
+	columns, m := make([]int, n), make([]int, 1<<uint(n))
+	var choose func(i int, span []bool)
+	choose = func(i int, span []bool) {
+		if i == n {
+			for x := range m {
+				m[x] = 0
+				for j := 0; j < n; j++ {
+					if x>>uint(j)&1 == 1 {
+						m[x] ^= columns[j]
+					}
+				}
+			}
+			f(m)
+			return
+		}
+		for v := 1; v < len(span); v++ {
+			if span[v] {
+				continue
+			}
+			columns[i] = v
+			next := make([]bool, len(span))
+			for z, in := range span {
+				if in {
+					next[z], next[z^v] = true, true
+				}
+			}
+			choose(i+1, next)
+		}
+	}
+	span := make([]bool, 1<<uint(n))
+	span[0] = true
+	choose(0, span)
+}
+
+// AffineEquivalent reports whether S-boxes a and b are affine equivalent
+func AffineEquivalent(a, b *SBox) (bool, error) {
+	if a.Size() != b.Size() {
+		return false, nil
+	}
+	ra, err := a.AffineRepresentative()
+	if err != nil {
+		return false, err
+	}
+	rb, err := b.AffineRepresentative()
+	if err != nil {
+		return false, err
+	}
+	return equal(ra.values, rb.values), nil
+}
+
+// Optimal reports whether S-box is an optimal 4-bit S-box
+func (s *SBox) Optimal() bool {
+	return s.bits == 4 && s.DifferentialUniformity() == 4 && s.Linearity() == 8
+}
+
+// OptimalClass returns index i of class Gi of OptimalClasses that S-box
+// belongs to, -1 if S-box is not optimal
+func (s *SBox) OptimalClass() (int, error) {
+	if !s.Optimal() {
+		return -1, nil
+	}
+	optimalOnce.Do(func() {
+		optimalRepresentatives = make([][]int, len(OptimalClasses))
+		for i, values := range OptimalClasses {
+			g, _ := New(values)
+			r, _ := g.AffineRepresentative()
+			optimalRepresentatives[i] = r.values
+		}
+	})
+	r, err := s.AffineRepresentative()
+	if err != nil {
+		return -1, err
+	}
+	for i, values := range optimalRepresentatives {
+		if equal(r.values, values) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("sbox: optimal S-box %x is out of classes G0, ..., G15", s.values)
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,239 @@
+package sbox
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Monomial is a product of input variables X and output variables Y given as
+// bit masks, x0 and y0 are the least significant bits
+type Monomial struct {
+	X, Y int
+}
+
+// Relation is a sum of monomials that is zero for all x and y == S(x)
+type Relation []Monomial
+
+// Degree returns count of variables of monomial
+func (m Monomial) Degree() int {
+	return Weight(m.X) + Weight(m.Y)
+}
+
+func (m Monomial) String() string {
+	if m.X == 0 && m.Y == 0 {
+		return "1"
+	}
+	b := new(strings.Builder)
+	for i := 0; m.X>>uint(i) != 0; i++ {
+		if m.X>>uint(i)&1 == 1 {
+			fmt.Fprintf(b, "x%d", i)
+		}
+	}
+	for i := 0; m.Y>>uint(i) != 0; i++ {
+		if m.Y>>uint(i)&1 == 1 {
+			fmt.Fprintf(b, "y%d", i)
+		}
+	}
+	return b.String()
+}
+
+func (r Relation) String() string {
+	if len(r) == 0 {
+		return "0"
+	}
+	terms := make([]string, len(r))
+	for i, m := range r {
+		terms[i] = m.String()
+	}
+	return strings.Join(terms, " + ")
+}
+
+// Mobius returns algebraic normal form of boolean function with truth table f,
+// ANF[u] is coefficient of monomial with variables of bit mask u
+func Mobius(f []int) []int {
+	anf := make([]int, len(f))
+	for x, v := range f {
+		anf[x] = v & 1
+	}
+	for step := 1; step < len(anf); step <<= 1 {
+		for x := range anf {
+			if x&step != 0 {
+				anf[x] ^= anf[x^step]
+			}
+		}
+	}
+	return anf
+}
+
+// Degree returns algebraic degree of ANF, -1 for zero function
+func Degree(anf []int) int {
+	degree := -1
+	for u, v := range anf {
+		if v != 0 && Weight(u) > degree {
+			degree = Weight(u)
+		}
+	}
+	return degree
+}
+
+// Monomials returns monomials of ANF ordered by descending degree
+func Monomials(anf []int) Relation {
+	monomials := make(Relation, 0)
+	for u, v := range anf {
+		if v != 0 {
+			monomials = append(monomials, Monomial{X: u})
+		}
+	}
+	sort.SliceStable(monomials, func(i, j int) bool {
+		return monomials[i].Degree() > monomials[j].Degree()
+	})
+	return monomials
+}
+
+// Component returns truth table of component function b·S(x)
+func (s *SBox) Component(b int) []int {
+	f := make([]int, s.Size())
+	for x := range f {
+		f[x] = Parity(b & s.values[x])
+	}
+	return f
+}
+
+// ANF returns algebraic normal forms of output coordinates, ANF()[i] is ANF
+// of the i-th output bit
+func (s *SBox) ANF() [][]int {
+	anf := make([][]int, s.bits)
+	for i := range anf {
+		anf[i] = Mobius(s.Component(1 << uint(i)))
+	}
+	return anf
+}
+
+// Degree returns algebraic degree of S-box, max degree of output coordinates
+func (s *SBox) Degree() int {
+	degree := 0
+	for _, anf := range s.ANF() {
+		if d := Degree(anf); d > degree {
+			degree = d
+		}
+	}
+	return degree
+}
+
+// MinDegree returns min algebraic degree of nonzero component functions
+func (s *SBox) MinDegree() int {
+	degree := s.bits
+	for b := 1; b < s.Size(); b++ {
+		if d := Degree(Mobius(s.Component(b))); d < degree {
+			degree = d
+		}
+	}
+	return degree
+}
+
+// BilinearRelations returns basis of relations built of monomials 1, xi, yj
+// and xiyj
+func (s *SBox) BilinearRelations() []Relation {
+	return s.relations(false)
+}
+
+// QuadraticRelations returns basis of relations of degree at most 2, it
+// includes bilinear ones
+func (s *SBox) QuadraticRelations() []Relation {
+	return s.relations(true)
+}
+
+// relations finds linear dependencies between evaluations of monomials over
+// points (x, S(x)) with Gaussian elimination over GF(2)
+func (s *SBox) relations(quadratic bool) []Relation {
+	monomials := Relation{{}}
+	for i := 0; i < s.bits; i++ {
+		monomials = append(monomials, Monomial{X: 1 << uint(i)})
+	}
+	for j := 0; j < s.bits; j++ {
+		monomials = append(monomials, Monomial{Y: 1 << uint(j)})
+	}
+	for i := 0; i < s.bits; i++ {
+		for j := 0; j < s.bits; j++ {
+			monomials = append(monomials, Monomial{X: 1 << uint(i), Y: 1 << uint(j)})
+		}
+	}
+	if quadratic {
+		for i := 0; i < s.bits; i++ {
+			for k := i + 1; k < s.bits; k++ {
+				monomials = append(monomials, Monomial{X: 1<<uint(i) | 1<<uint(k)})
+				monomials = append(monomials, Monomial{Y: 1<<uint(i) | 1<<uint(k)})
+			}
+		}
+	}
+
+	type row struct {
+		values, combination bitset
+		pivot               int
+	}
+	basis := make([]row, 0)
+	relations := make([]Relation, 0)
+	for m, monomial := range monomials {
+		r := row{newBitset(s.Size()), newBitset(len(monomials)), -1}
+		for x, y := range s.values {
+			if x&monomial.X == monomial.X && y&monomial.Y == monomial.Y {
+				r.values.set(x)
+			}
+		}
+		r.combination.set(m)
+		for _, b := range basis {
+			if r.values.get(b.pivot) {
+				r.values.xor(b.values)
+				r.combination.xor(b.combination)
+			}
+		}
+		if r.pivot = r.values.first(); r.pivot != -1 {
+			basis = append(basis, r)
+			continue
+		}
+		relation := make(Relation, 0)
+		for i := range monomials {
+			if r.combination.get(i) {
+				relation = append(relation, monomials[i])
+			}
+		}
+		sort.SliceStable(relation, func(i, j int) bool {
+			return relation[i].Degree() > relation[j].Degree()
+		})
+		relations = append(relations, relation)
+	}
+	return relations
+}
+
+type bitset []uint64
+
+func newBitset(size int) bitset {
+	return make(bitset, (size+63)/64)
+}
+
+func (b bitset) set(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+func (b bitset) get(i int) bool {
+	return b[i/64]>>uint(i%64)&1 == 1
+}
+
+func (b bitset) xor(other bitset) {
+	for i := range b {
+		b[i] ^= other[i]
+	}
+}
+
+// first returns index of the lowest set bit, -1 if bitset is empty
+func (b bitset) first() int {
+	for i, w := range b {
+		for j := 0; j < 64; j++ {
+			if w>>uint(j)&1 == 1 {
+				return i*64 + j
+			}
+		}
+	}
+	return -1
+}
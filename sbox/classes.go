@@ -0,0 +1,342 @@
+package sbox
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AffineClasses are representatives of all 302 affine equivalence classes of
+// 4-bit permutations in lexicographic order, every representative is
+// AffineRepresentative of its class, so the first one is identity
+var AffineClasses = [][]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 15, 14},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 12, 15, 14},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 14, 15, 12},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 12, 11, 13, 15, 14},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 12, 11, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 12, 13, 11, 15, 14},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 12, 13, 14, 11, 15},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 12, 13, 14, 15, 11},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 11, 10, 14, 15, 13, 12},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 11, 12, 10, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 11, 12, 14, 10, 15, 13},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 11, 12, 14, 15, 13, 10},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 12, 13, 14, 15, 10, 11},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 12, 13, 14, 15, 11, 10},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 12, 14, 11, 15, 13, 10},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 12, 14, 15, 11, 13, 10},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 14, 11, 9, 15, 13},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 10, 11, 12, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 10, 11, 13, 14, 15, 12},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 10, 12, 11, 13, 15, 14},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 10, 12, 11, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 10, 12, 13, 11, 15, 14},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 10, 12, 13, 14, 11, 15},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 10, 12, 13, 14, 15, 11},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 10, 12, 13, 15, 11, 14},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 10, 12, 15, 11, 13, 14},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 10, 12, 15, 14, 13, 11},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 11, 10, 14, 15, 13, 12},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 11, 12, 10, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 11, 12, 13, 14, 15, 10},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 11, 12, 14, 10, 15, 13},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 11, 12, 14, 15, 10, 13},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 11, 12, 14, 15, 13, 10},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 11, 12, 15, 14, 10, 13},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 12, 13, 14, 15, 10, 11},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 12, 13, 14, 15, 11, 10},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 12, 14, 10, 15, 11, 13},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 12, 14, 10, 15, 13, 11},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 12, 14, 11, 15, 13, 10},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 9, 12, 14, 15, 11, 13, 10},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 10, 9, 12, 11, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 10, 9, 12, 13, 15, 11, 14},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 10, 9, 12, 15, 11, 13, 14},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 10, 11, 9, 13, 14, 15, 12},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 10, 11, 12, 9, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 10, 11, 12, 9, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 10, 11, 12, 13, 14, 15, 9},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 10, 11, 12, 14, 9, 15, 13},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 10, 11, 12, 14, 15, 13, 9},
+	{0, 1, 2, 3, 4, 5, 6, 8, 7, 10, 12, 14, 11, 9, 15, 13},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 9, 12, 13, 14, 15, 11, 10},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 9, 12, 14, 10, 15, 11, 13},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 9, 12, 14, 10, 15, 13, 11},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 10, 9, 11, 12, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 10, 9, 11, 12, 15, 14, 13},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 10, 9, 12, 11, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 10, 9, 12, 11, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 10, 9, 12, 11, 15, 14, 13},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 10, 9, 12, 13, 14, 11, 15},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 10, 9, 12, 13, 14, 15, 11},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 10, 9, 12, 13, 15, 11, 14},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 10, 9, 12, 13, 15, 14, 11},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 10, 12, 14, 9, 11, 15, 13},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 10, 12, 15, 9, 11, 13, 14},
+	{0, 1, 2, 3, 4, 5, 7, 6, 8, 10, 12, 15, 9, 11, 14, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 10, 12, 11, 14, 13, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 10, 12, 11, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 10, 12, 11, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 10, 12, 13, 14, 11, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 10, 12, 13, 15, 14, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 10, 12, 14, 11, 13, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 10, 12, 14, 11, 15, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 10, 12, 14, 13, 11, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 10, 12, 14, 15, 13, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 10, 12, 15, 11, 13, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 10, 12, 15, 14, 11, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 11, 12, 13, 14, 15, 10},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 11, 12, 14, 10, 15, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 12, 13, 14, 15, 11, 10},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 12, 14, 10, 15, 11, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 12, 14, 10, 15, 13, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 12, 14, 11, 15, 13, 10},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 9, 12, 14, 15, 11, 13, 10},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 11, 12, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 11, 13, 15, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 11, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 11, 15, 14, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 13, 11, 14, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 13, 14, 11, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 13, 15, 14, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 14, 11, 13, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 14, 13, 11, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 14, 13, 15, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 14, 15, 13, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 15, 11, 13, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 15, 11, 14, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 15, 13, 11, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 9, 12, 15, 13, 14, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 9, 12, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 9, 14, 13, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 9, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 9, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 9, 15, 14, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 13, 14, 9, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 13, 14, 15, 9},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 14, 9, 13, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 14, 9, 15, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 14, 13, 9, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 14, 13, 15, 9},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 15, 9, 13, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 15, 9, 14, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 15, 13, 9, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 11, 12, 15, 13, 14, 9},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 9, 11, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 9, 11, 15, 14, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 9, 13, 14, 11, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 9, 13, 14, 15, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 9, 14, 13, 11, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 9, 14, 13, 15, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 9, 15, 11, 14, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 11, 9, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 11, 9, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 11, 9, 15, 14, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 11, 13, 14, 9, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 11, 13, 15, 14, 9},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 11, 14, 9, 13, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 11, 14, 13, 9, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 11, 15, 9, 14, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 13, 9, 14, 11, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 13, 9, 14, 15, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 13, 9, 15, 11, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 13, 9, 15, 14, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 13, 11, 9, 14, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 13, 14, 9, 11, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 13, 14, 9, 15, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 13, 15, 9, 11, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 13, 15, 9, 14, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 14, 9, 11, 15, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 14, 9, 13, 15, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 14, 11, 9, 13, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 14, 11, 13, 9, 15},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 14, 11, 15, 13, 9},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 14, 13, 9, 15, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 14, 13, 15, 11, 9},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 14, 15, 11, 9, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 15, 9, 11, 13, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 15, 9, 11, 14, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 15, 9, 13, 11, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 15, 9, 14, 11, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 15, 11, 9, 13, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 15, 11, 9, 14, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 15, 11, 13, 9, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 15, 11, 13, 14, 9},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 15, 13, 9, 11, 14},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 15, 13, 14, 9, 11},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 15, 13, 14, 11, 9},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 15, 14, 11, 9, 13},
+	{0, 1, 2, 3, 4, 5, 7, 8, 6, 10, 12, 15, 14, 13, 9, 11},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 7, 12, 13, 14, 15, 10, 11},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 7, 12, 13, 14, 15, 11, 10},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 7, 12, 14, 10, 15, 11, 13},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 7, 12, 14, 10, 15, 13, 11},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 7, 12, 14, 11, 15, 13, 10},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 7, 12, 14, 15, 11, 13, 10},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 10, 11, 7, 12, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 10, 11, 7, 12, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 10, 11, 12, 7, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 10, 11, 12, 7, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 10, 11, 12, 14, 7, 13, 15},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 10, 11, 12, 14, 13, 15, 7},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 10, 11, 12, 15, 13, 7, 14},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 10, 12, 14, 7, 11, 13, 15},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 10, 12, 14, 7, 11, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 10, 12, 14, 11, 7, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 10, 12, 14, 15, 13, 11, 7},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 10, 12, 15, 7, 11, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 9, 6, 10, 12, 15, 7, 11, 14, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 7, 11, 9, 12, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 7, 11, 12, 9, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 7, 11, 12, 9, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 7, 11, 12, 14, 13, 15, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 7, 12, 14, 11, 9, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 7, 12, 15, 9, 11, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 7, 12, 15, 9, 11, 14, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 7, 12, 11, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 7, 12, 11, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 11, 7, 12, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 11, 7, 12, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 11, 12, 7, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 11, 12, 7, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 11, 12, 14, 7, 13, 15},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 11, 12, 14, 13, 15, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 11, 12, 15, 7, 14, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 11, 12, 15, 13, 7, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 7, 11, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 7, 11, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 7, 13, 14, 11, 15},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 7, 13, 15, 14, 11},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 7, 15, 11, 14, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 7, 15, 13, 11, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 11, 7, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 11, 7, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 13, 7, 15, 14, 11},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 13, 14, 7, 15, 11},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 14, 7, 15, 11, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 14, 7, 15, 13, 11},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 14, 11, 7, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 14, 11, 13, 15, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 14, 11, 15, 13, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 14, 13, 7, 15, 11},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 14, 15, 7, 13, 11},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 14, 15, 11, 7, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 14, 15, 11, 13, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 15, 7, 14, 11, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 15, 11, 7, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 15, 11, 14, 13, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 15, 13, 7, 11, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 15, 13, 14, 11, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 15, 14, 7, 11, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 9, 12, 15, 14, 11, 7, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 7, 12, 9, 14, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 7, 12, 9, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 7, 12, 13, 15, 14, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 7, 12, 14, 13, 15, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 9, 7, 12, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 9, 12, 13, 14, 7, 15},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 9, 12, 14, 13, 15, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 9, 12, 15, 7, 14, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 7, 13, 14, 9, 15},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 7, 13, 15, 14, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 7, 14, 13, 15, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 7, 15, 9, 14, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 9, 7, 15, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 9, 13, 14, 7, 15},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 13, 14, 9, 15, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 14, 7, 9, 15, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 14, 13, 7, 9, 15},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 14, 13, 9, 7, 15},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 14, 13, 15, 9, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 15, 7, 13, 9, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 15, 7, 14, 9, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 15, 9, 7, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 15, 9, 7, 14, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 15, 9, 13, 7, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 15, 13, 7, 9, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 15, 13, 9, 14, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 15, 13, 14, 9, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 11, 12, 15, 14, 13, 7, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 7, 13, 11, 9, 15, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 7, 13, 11, 14, 15, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 7, 13, 11, 15, 14, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 7, 13, 15, 14, 11, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 7, 14, 11, 13, 15, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 7, 14, 11, 15, 13, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 7, 15, 11, 9, 13, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 7, 15, 11, 13, 9, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 7, 15, 11, 14, 9, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 7, 15, 11, 14, 13, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 7, 15, 13, 11, 9, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 7, 15, 14, 11, 9, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 9, 13, 7, 14, 15, 11},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 9, 13, 7, 15, 11, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 9, 13, 7, 15, 14, 11},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 9, 13, 11, 15, 7, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 9, 13, 11, 15, 14, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 9, 15, 11, 13, 7, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 9, 15, 13, 11, 7, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 9, 15, 13, 11, 14, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 9, 15, 14, 11, 7, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 11, 9, 15, 7, 14, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 11, 13, 7, 14, 9, 15},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 11, 13, 14, 7, 9, 15},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 11, 13, 14, 9, 7, 15},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 11, 15, 7, 14, 9, 13},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 11, 15, 14, 7, 13, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 14, 9, 11, 13, 15, 7},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 14, 11, 7, 15, 13, 9},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 15, 7, 11, 13, 9, 14},
+	{0, 1, 2, 3, 4, 5, 8, 10, 6, 12, 15, 7, 11, 14, 9, 13},
+	{0, 1, 2, 3, 4, 6, 7, 8, 5, 9, 12, 15, 11, 14, 10, 13},
+	{0, 1, 2, 3, 4, 6, 7, 8, 5, 9, 12, 15, 14, 10, 13, 11},
+	{0, 1, 2, 3, 4, 6, 7, 8, 5, 9, 12, 15, 14, 11, 13, 10},
+	{0, 1, 2, 3, 4, 6, 8, 10, 5, 9, 12, 15, 13, 14, 7, 11},
+	{0, 1, 2, 3, 4, 6, 8, 10, 5, 11, 12, 15, 7, 9, 13, 14},
+	{0, 1, 2, 3, 4, 6, 8, 10, 5, 11, 12, 15, 7, 13, 9, 14},
+	{0, 1, 2, 3, 4, 6, 8, 10, 5, 11, 12, 15, 7, 14, 9, 13},
+	{0, 1, 2, 3, 4, 6, 8, 10, 5, 11, 12, 15, 13, 14, 7, 9},
+	{0, 1, 2, 3, 4, 6, 8, 10, 5, 11, 12, 15, 14, 13, 9, 7},
+	{0, 1, 2, 3, 4, 6, 8, 11, 5, 9, 12, 14, 13, 7, 10, 15},
+	{0, 1, 2, 3, 4, 6, 8, 11, 5, 9, 12, 14, 13, 10, 7, 15},
+	{0, 1, 2, 3, 4, 6, 8, 11, 5, 9, 12, 15, 7, 13, 10, 14},
+	{0, 1, 2, 3, 4, 6, 8, 11, 5, 12, 9, 13, 10, 15, 14, 7},
+	{0, 1, 2, 3, 4, 6, 8, 11, 5, 12, 9, 13, 14, 7, 10, 15},
+	{0, 1, 2, 3, 4, 6, 8, 11, 5, 12, 9, 13, 14, 10, 7, 15},
+	{0, 1, 2, 3, 4, 6, 8, 11, 5, 12, 13, 7, 9, 15, 10, 14},
+	{0, 1, 2, 3, 4, 6, 8, 11, 5, 12, 13, 7, 10, 15, 9, 14},
+	{0, 1, 2, 3, 4, 6, 8, 11, 5, 12, 13, 7, 15, 9, 14, 10},
+	{0, 1, 2, 3, 4, 6, 8, 12, 5, 9, 11, 13, 14, 7, 10, 15},
+	{0, 1, 2, 3, 4, 6, 8, 12, 5, 9, 11, 13, 14, 10, 7, 15},
+	{0, 1, 2, 3, 4, 6, 8, 12, 5, 9, 13, 15, 10, 7, 11, 14},
+}
+
+// AffineClass returns index of class of 4-bit S-box in AffineClasses
+func (s *SBox) AffineClass() (int, error) {
+	if s.bits != 4 {
+		return -1, fmt.Errorf("sbox: affine classes of %d-bit S-box are not numbered", s.bits)
+	}
+	r, err := s.AffineRepresentative()
+	if err != nil {
+		return -1, err
+	}
+	i := sort.Search(len(AffineClasses), func(i int) bool {
+		return !less(AffineClasses[i], r.values)
+	})
+	if i == len(AffineClasses) || !equal(AffineClasses[i], r.values) {
+		return -1, fmt.Errorf("sbox: representative %x of S-box is out of affine classes", r.values)
+	}
+	return i, nil
+}
+
+// less reports whether a is lexicographically less than b of the same length
+func less(a, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
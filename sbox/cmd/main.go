@@ -0,0 +1,321 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/mariiatuzovska/cryptanalysis/sbox"
+	"github.com/urfave/cli"
+)
+
+func main() {
+
+	app := cli.NewApp()
+	app.Name = "sbox"
+	app.Usage = "S-box analysis of Heys cipher command line client"
+	app.Description = "S-box analysis of Heys cipher"
+	app.Version = "0.0.1"
+	app.Copyright = "2020, mariiatuzovska"
+	app.Authors = []cli.Author{cli.Author{Name: "Tuzovska Mariia"}}
+	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:  "spec",
+			Usage: "JSON cipher specification with S-box, heys.SBlocks if empty",
+		},
+		&cli.StringFlag{
+			Name:  "sbox",
+			Usage: "hex S-box values as f8e9720dc615b43a or comma separated, overrides --spec",
+		},
+	}
+	app.Commands = []cli.Command{
+		{
+			Name:  "ddt",
+			Usage: "shows difference distribution table",
+			Action: func(c *cli.Context) error {
+				s, err := loadSBox(c)
+				if err != nil {
+					return err
+				}
+				return sbox.WriteTable(os.Stdout, "DDT", s.DDT())
+			},
+		},
+		{
+			Name:  "lat",
+			Usage: "shows linear approximation table",
+			Action: func(c *cli.Context) error {
+				s, err := loadSBox(c)
+				if err != nil {
+					return err
+				}
+				return sbox.WriteTable(os.Stdout, "LAT", s.LAT())
+			},
+		},
+		{
+			Name:  "bct",
+			Usage: "shows boomerang connectivity table",
+			Action: func(c *cli.Context) error {
+				s, err := loadSBox(c)
+				if err != nil {
+					return err
+				}
+				return sbox.WriteTable(os.Stdout, "BCT", s.BCT())
+			},
+		},
+		{
+			Name:  "dlct",
+			Usage: "shows differential-linear connectivity table",
+			Action: func(c *cli.Context) error {
+				s, err := loadSBox(c)
+				if err != nil {
+					return err
+				}
+				return sbox.WriteTable(os.Stdout, "DLCT", s.DLCT())
+			},
+		},
+		{
+			Name:  "anf",
+			Usage: "shows algebraic normal form of output coordinates",
+			Action: func(c *cli.Context) error {
+				s, err := loadSBox(c)
+				if err != nil {
+					return err
+				}
+				for i, anf := range s.ANF() {
+					monomials := sbox.Monomials(anf)
+					fmt.Println(fmt.Sprintf("y%d = %s", i, monomials))
+					fmt.Println(fmt.Sprintf("     degree %d, monomials %d", sbox.Degree(anf), len(monomials)))
+				}
+				fmt.Println(fmt.Sprintf("algebraic degree %d, min degree of components %d", s.Degree(), s.MinDegree()))
+				return nil
+			},
+		},
+		{
+			Name:  "relations",
+			Usage: "shows bilinear and quadratic relations between inputs x and outputs y",
+			Action: func(c *cli.Context) error {
+				s, err := loadSBox(c)
+				if err != nil {
+					return err
+				}
+				bilinear, quadratic := s.BilinearRelations(), s.QuadraticRelations()
+				fmt.Println(fmt.Sprintf("%d bilinear relations", len(bilinear)))
+				for _, r := range bilinear {
+					fmt.Println(fmt.Sprintf("  %s = 0", r))
+				}
+				fmt.Println(fmt.Sprintf("%d quadratic relations", len(quadratic)))
+				for _, r := range quadratic {
+					fmt.Println(fmt.Sprintf("  %s = 0", r))
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "class",
+			Usage: "shows canonical affine equivalence representative, its class of 302 classes of 4-bit permutations and optimal class G0-G15 of S-box",
+			Action: func(c *cli.Context) error {
+				s, err := loadSBox(c)
+				if err != nil {
+					return err
+				}
+				r, err := s.AffineRepresentative()
+				if err != nil {
+					return err
+				}
+				class, err := s.OptimalClass()
+				if err != nil {
+					return err
+				}
+				fmt.Println(fmt.Sprintf("S-box          %s", formatSBox(s.Values())))
+				fmt.Println(fmt.Sprintf("representative %s", formatSBox(r.Values())))
+				if s.Bits() == 4 {
+					affine, err := s.AffineClass()
+					if err != nil {
+						return err
+					}
+					fmt.Println(fmt.Sprintf("S-box is of affine class #%d of %d", affine, len(sbox.AffineClasses)))
+				}
+				if class == -1 {
+					fmt.Println("S-box is not optimal")
+				} else {
+					fmt.Println(fmt.Sprintf("S-box is optimal of class G%d", class))
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "compare",
+			Usage: "compares S-box with PRESENT and Serpent S-boxes",
+			Action: func(c *cli.Context) error {
+				s, err := loadSBox(c)
+				if err != nil {
+					return err
+				}
+				names, values := []string{"S-box"}, [][]int{s.Values()}
+				names, values = append(names, "PRESENT"), append(values, sbox.PRESENT)
+				for i, v := range sbox.Serpent {
+					names, values = append(names, fmt.Sprintf("Serpent S%d", i)), append(values, v)
+				}
+				fmt.Println(fmt.Sprintf("%-10s  %-16s  %2s  %3s  %3s  %3s  %s", "name", "values", "DU", "LIN", "DEG", "BU", "class"))
+				for i, v := range values {
+					s, err := sbox.New(v)
+					if err != nil {
+						return err
+					}
+					class, err := s.OptimalClass()
+					if err != nil {
+						return err
+					}
+					name := "-"
+					if class != -1 {
+						name = fmt.Sprintf("G%d", class)
+					}
+					fmt.Println(fmt.Sprintf("%-10s  %-16s  %2d  %3d  %3d  %3d  %s", names[i], formatSBox(s.Values()),
+						s.DifferentialUniformity(), s.Linearity(), s.Degree(), s.BoomerangUniformity(), name))
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "generate",
+			Usage: "generates random S-box with constraints and writes cipher specification with it",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:  "bits",
+					Value: 4,
+				},
+				&cli.IntFlag{
+					Name:  "max-ddt",
+					Usage: "max DDT entry, not checked if 0",
+				},
+				&cli.IntFlag{
+					Name:  "max-lat",
+					Usage: "max absolute LAT entry, not checked if 0",
+				},
+				&cli.BoolFlag{
+					Name: "no-fixed-points",
+				},
+				&cli.IntFlag{
+					Name:  "min-degree",
+					Usage: "min algebraic degree of components, not checked if 0",
+				},
+				&cli.IntFlag{
+					Name:  "attempts",
+					Value: 1000000,
+				},
+				&cli.Int64Flag{
+					Name:  "seed",
+					Value: 1,
+				},
+				&cli.StringFlag{
+					Name:  "out",
+					Value: "spec.json",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				r := rand.New(rand.NewSource(c.Int64("seed")))
+				s, attempts, err := sbox.Generate(r, c.Int("bits"), sbox.Constraints{
+					MaxDDT:        c.Int("max-ddt"),
+					MaxLAT:        c.Int("max-lat"),
+					NoFixedPoints: c.Bool("no-fixed-points"),
+					MinDegree:     c.Int("min-degree"),
+				}, c.Int("attempts"))
+				if err != nil {
+					return err
+				}
+				spec := heys.DefaultSpec()
+				spec.Width, spec.Count, spec.SBox = s.Bits(), 16/s.Bits(), s.Values()
+				spec.Permutation = heys.TransposePermutation(spec.Width, spec.Count)
+				if spec.Width*spec.Count != 16 {
+					spec.Keys = heys.RandomSPNKeys(r, spec.Width*spec.Count, spec.Rounds)
+				}
+				if _, err = spec.Cipher(); err != nil {
+					return err
+				}
+				if err = spec.Save(c.String("out")); err != nil {
+					return err
+				}
+				fmt.Println(fmt.Sprintf("S-box %s found after %d attempts, specification is written to %s",
+					formatSBox(s.Values()), attempts, c.String("out")))
+				return nil
+			},
+		},
+		{
+			Name:  "report",
+			Usage: "shows cryptographic properties of S-box",
+			Action: func(c *cli.Context) error {
+				s, err := loadSBox(c)
+				if err != nil {
+					return err
+				}
+				fmt.Println(fmt.Sprintf("S-box                      %s", formatSBox(s.Values())))
+				fmt.Println(fmt.Sprintf("differential uniformity    %d", s.DifferentialUniformity()))
+				fmt.Println(fmt.Sprintf("linearity                  %d", s.Linearity()))
+				fmt.Println(fmt.Sprintf("nonlinearity               %d", s.Nonlinearity()))
+				fmt.Println(fmt.Sprintf("boomerang uniformity       %d", s.BoomerangUniformity()))
+				fmt.Println(fmt.Sprintf("diff-linear uniformity     %d", s.DifferentialLinearUniformity()))
+				fmt.Println(fmt.Sprintf("algebraic degree           %d", s.Degree()))
+				fmt.Println(fmt.Sprintf("fixed points               %x", s.FixedPoints()))
+				fmt.Println(fmt.Sprintf("differential branch number %d", s.DifferentialBranchNumber()))
+				fmt.Println(fmt.Sprintf("linear branch number       %d", s.LinearBranchNumber()))
+				return nil
+			},
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func loadSBox(c *cli.Context) (*sbox.SBox, error) {
+	if c.GlobalString("sbox") != "" {
+		values, err := parseSBox(c.GlobalString("sbox"))
+		if err != nil {
+			return nil, err
+		}
+		return sbox.New(values)
+	}
+	spec := heys.DefaultSpec()
+	if c.GlobalString("spec") != "" {
+		var err error
+		if spec, err = heys.LoadSpec(c.GlobalString("spec")); err != nil {
+			return nil, err
+		}
+	}
+	return sbox.New(spec.SBox)
+}
+
+func parseSBox(s string) ([]int, error) {
+	var fields []string
+	if strings.Contains(s, ",") {
+		fields = strings.Split(s, ",")
+	} else {
+		fields = strings.Split(s, "")
+	}
+	values := make([]int, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(field), "0x"), 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("sbox: %v", err)
+		}
+		values[i] = int(v)
+	}
+	return values, nil
+}
+
+// formatSBox returns S-box in format of --sbox flag
+func formatSBox(values []int) string {
+	fields := make([]string, len(values))
+	for i, v := range values {
+		fields[i] = fmt.Sprintf("%x", v)
+	}
+	if len(values) <= 16 {
+		return strings.Join(fields, "")
+	}
+	return strings.Join(fields, ",")
+}
@@ -0,0 +1,62 @@
+package sbox
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Constraints are target properties of generated S-box, zero fields are not
+// checked
+type Constraints struct {
+	// MaxDDT is max DDT entry for nonzero input difference
+	MaxDDT int
+	// MaxLAT is max absolute LAT entry for nonzero masks, bias is MaxLAT/size
+	MaxLAT int
+	// NoFixedPoints requires S(x) != x for all x
+	NoFixedPoints bool
+	// MinDegree is min algebraic degree of nonzero component functions
+	MinDegree int
+}
+
+// Check returns error that describes the first violated constraint
+func (c Constraints) Check(s *SBox) error {
+	if c.NoFixedPoints {
+		if points := s.FixedPoints(); len(points) != 0 {
+			return fmt.Errorf("sbox: S-box has %d fixed points", len(points))
+		}
+	}
+	if c.MaxDDT != 0 {
+		if du := s.DifferentialUniformity(); du > c.MaxDDT {
+			return fmt.Errorf("sbox: max DDT entry %d is greater than %d", du, c.MaxDDT)
+		}
+	}
+	if c.MaxLAT != 0 {
+		if lat := s.Linearity() / 2; lat > c.MaxLAT {
+			return fmt.Errorf("sbox: max LAT entry %d is greater than %d", lat, c.MaxLAT)
+		}
+	}
+	if c.MinDegree != 0 {
+		if degree := s.MinDegree(); degree < c.MinDegree {
+			return fmt.Errorf("sbox: min degree %d is less than %d", degree, c.MinDegree)
+		}
+	}
+	return nil
+}
+
+// Generate returns random bijective S-box of bits that satisfies constraints
+// and count of tried permutations, it fails after attempts permutations
+func Generate(r *rand.Rand, bits int, constraints Constraints, attempts int) (*SBox, int, error) {
+	if bits < 1 || bits > 8 {
+		return nil, 0, fmt.Errorf("sbox: S-box of %d bits is not supported", bits)
+	}
+	for i := 1; i <= attempts; i++ {
+		s, err := New(r.Perm(1 << uint(bits)))
+		if err != nil {
+			return nil, i, err
+		}
+		if constraints.Check(s) == nil {
+			return s, i, nil
+		}
+	}
+	return nil, attempts, fmt.Errorf("sbox: no S-box satisfies constraints after %d attempts", attempts)
+}
@@ -0,0 +1,271 @@
+package sbox
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+)
+
+// SBox is a bijective S-box of n bits
+type SBox struct {
+	bits    int
+	values  []int
+	inverse []int
+}
+
+// New returns S-box with values, count of values must be a power of two and
+// values must be bijective
+func New(values []int) (*SBox, error) {
+	inverse, err := heys.InverseSBox(values)
+	if err != nil {
+		return nil, err
+	}
+	s := &SBox{
+		values:  make([]int, len(values)),
+		inverse: inverse,
+	}
+	copy(s.values, values)
+	for 1<<uint(s.bits) < len(values) {
+		s.bits++
+	}
+	return s, nil
+}
+
+// Bits returns bit width of S-box
+func (s *SBox) Bits() int {
+	return s.bits
+}
+
+// Size returns count of S-box values
+func (s *SBox) Size() int {
+	return len(s.values)
+}
+
+// Values returns copy of S-box values
+func (s *SBox) Values() []int {
+	values := make([]int, len(s.values))
+	copy(values, s.values)
+	return values
+}
+
+// Inverse returns inverse S-box
+func (s *SBox) Inverse() *SBox {
+	inverse := &SBox{
+		bits:    s.bits,
+		values:  make([]int, len(s.inverse)),
+		inverse: s.Values(),
+	}
+	copy(inverse.values, s.inverse)
+	return inverse
+}
+
+// Lookup returns S(x)
+func (s *SBox) Lookup(x int) int {
+	return s.values[x]
+}
+
+// DDT returns difference distribution table, DDT[a][b] is count of x with
+// S(x)^S(x^a) == b
+func (s *SBox) DDT() [][]int {
+	ddt := newTable(s.Size())
+	for a := 0; a < s.Size(); a++ {
+		for x := 0; x < s.Size(); x++ {
+			ddt[a][s.values[x]^s.values[x^a]]++
+		}
+	}
+	return ddt
+}
+
+// LAT returns linear approximation table, LAT[a][b] is count of x with
+// a·x == b·S(x) minus half of S-box size
+func (s *SBox) LAT() [][]int {
+	lat := newTable(s.Size())
+	for a := 0; a < s.Size(); a++ {
+		for b := 0; b < s.Size(); b++ {
+			for x := 0; x < s.Size(); x++ {
+				if Parity(a&x) == Parity(b&s.values[x]) {
+					lat[a][b]++
+				}
+			}
+			lat[a][b] -= s.Size() / 2
+		}
+	}
+	return lat
+}
+
+// BCT returns boomerang connectivity table, BCT[a][b] is count of x with
+// S⁻¹(S(x)^b) ^ S⁻¹(S(x^a)^b) == a
+func (s *SBox) BCT() [][]int {
+	bct := newTable(s.Size())
+	for a := 0; a < s.Size(); a++ {
+		for b := 0; b < s.Size(); b++ {
+			for x := 0; x < s.Size(); x++ {
+				if s.inverse[s.values[x]^b]^s.inverse[s.values[x^a]^b] == a {
+					bct[a][b]++
+				}
+			}
+		}
+	}
+	return bct
+}
+
+// DLCT returns differential-linear connectivity table, DLCT[a][b] is count of
+// x with b·(S(x)^S(x^a)) == 0 minus half of S-box size
+func (s *SBox) DLCT() [][]int {
+	dlct := newTable(s.Size())
+	for a := 0; a < s.Size(); a++ {
+		for b := 0; b < s.Size(); b++ {
+			for x := 0; x < s.Size(); x++ {
+				if Parity(b&(s.values[x]^s.values[x^a])) == 0 {
+					dlct[a][b]++
+				}
+			}
+			dlct[a][b] -= s.Size() / 2
+		}
+	}
+	return dlct
+}
+
+// DifferentialUniformity returns max DDT entry for nonzero input difference
+func (s *SBox) DifferentialUniformity() int {
+	return maxEntry(s.DDT())
+}
+
+// Linearity returns max absolute Walsh coefficient for nonzero output mask,
+// it is twice max absolute LAT entry
+func (s *SBox) Linearity() int {
+	return 2 * maxEntry(s.LAT())
+}
+
+// BoomerangUniformity returns max BCT entry for nonzero differences
+func (s *SBox) BoomerangUniformity() int {
+	return maxEntry(s.BCT())
+}
+
+// DifferentialLinearUniformity returns max absolute DLCT entry for nonzero
+// input difference and output mask
+func (s *SBox) DifferentialLinearUniformity() int {
+	return maxEntry(s.DLCT())
+}
+
+// Nonlinearity returns distance to the closest affine function of nonzero
+// linear combination of S-box coordinates
+func (s *SBox) Nonlinearity() int {
+	return s.Size()/2 - s.Linearity()/2
+}
+
+// FixedPoints returns x with S(x) == x
+func (s *SBox) FixedPoints() []int {
+	points := make([]int, 0)
+	for x, y := range s.values {
+		if x == y {
+			points = append(points, x)
+		}
+	}
+	return points
+}
+
+// DifferentialBranchNumber returns min of wt(a)+wt(b) for nonzero input
+// difference a and output difference b with nonzero DDT entry
+func (s *SBox) DifferentialBranchNumber() int {
+	return branchNumber(s.DDT())
+}
+
+// LinearBranchNumber returns min of wt(a)+wt(b) for nonzero masks a and b
+// with nonzero LAT entry
+func (s *SBox) LinearBranchNumber() int {
+	return branchNumber(s.LAT())
+}
+
+// Parity returns parity of bits of x
+func Parity(x int) int {
+	p := 0
+	for ; x != 0; x &= x - 1 {
+		p ^= 1
+	}
+	return p
+}
+
+// Weight returns count of nonzero bits of x
+func Weight(x int) int {
+	w := 0
+	for ; x != 0; x &= x - 1 {
+		w++
+	}
+	return w
+}
+
+// WriteTable writes table with hex row and column headers
+func WriteTable(w io.Writer, name string, table [][]int) error {
+	width := len(name)
+	for _, row := range table {
+		for _, v := range row {
+			if n := len(fmt.Sprint(v)); n > width {
+				width = n
+			}
+		}
+	}
+	if n := len(fmt.Sprintf("%x", len(table)-1)); n > width {
+		width = n
+	}
+	b := new(strings.Builder)
+	fmt.Fprintf(b, "%*s |", width, name)
+	for j := range table[0] {
+		fmt.Fprintf(b, " %*x", width, j)
+	}
+	fmt.Fprintf(b, "\n%s\n", strings.Repeat("-", (width+1)*(len(table[0])+1)+1))
+	for i, row := range table {
+		fmt.Fprintf(b, "%*x |", width, i)
+		for _, v := range row {
+			if v == 0 {
+				fmt.Fprintf(b, " %*s", width, ".")
+			} else {
+				fmt.Fprintf(b, " %*d", width, v)
+			}
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func newTable(size int) [][]int {
+	table := make([][]int, size)
+	for i := range table {
+		table[i] = make([]int, size)
+	}
+	return table
+}
+
+// maxEntry returns max absolute entry of table without zero row and column
+func maxEntry(table [][]int) int {
+	max := 0
+	for a := 1; a < len(table); a++ {
+		for b := 1; b < len(table[a]); b++ {
+			v := table[a][b]
+			if v < 0 {
+				v = -v
+			}
+			if v > max {
+				max = v
+			}
+		}
+	}
+	return max
+}
+
+func branchNumber(table [][]int) int {
+	min := -1
+	for a := 0; a < len(table); a++ {
+		for b := 0; b < len(table[a]); b++ {
+			if (a != 0 || b != 0) && table[a][b] != 0 {
+				if w := Weight(a) + Weight(b); min == -1 || w < min {
+					min = w
+				}
+			}
+		}
+	}
+	return min
+}
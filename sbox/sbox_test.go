@@ -0,0 +1,175 @@
+package sbox
+
+import (
+	"testing"
+
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+)
+
+// newSBox returns S-box of values
+func newSBox(t *testing.T, values []int) *SBox {
+	t.Helper()
+	s, err := New(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestHeysMetrics(t *testing.T) {
+	s := newSBox(t, heys.SBlocks)
+	metrics := []struct {
+		name      string
+		got, want int
+	}{
+		{"differential uniformity", s.DifferentialUniformity(), 4},
+		{"linearity", s.Linearity(), 8},
+		{"nonlinearity", s.Nonlinearity(), 4},
+		{"differential branch number", s.DifferentialBranchNumber(), 2},
+		{"linear branch number", s.LinearBranchNumber(), 2},
+		{"fixed points", len(s.FixedPoints()), 0},
+		{"boomerang uniformity", s.BoomerangUniformity(), 10},
+		{"differential-linear uniformity", s.DifferentialLinearUniformity(), 4},
+		{"degree", s.Degree(), 3},
+		{"min degree of components", s.MinDegree(), 3},
+		{"bilinear relations", len(s.BilinearRelations()), 9},
+		{"quadratic relations", len(s.QuadraticRelations()), 21},
+	}
+	for _, m := range metrics {
+		if m.got != m.want {
+			t.Errorf("Heys S-box %s is %d, want %d", m.name, m.got, m.want)
+		}
+	}
+	if !s.Optimal() {
+		t.Error("Heys S-box is not optimal")
+	}
+}
+
+func TestTables(t *testing.T) {
+	s := newSBox(t, heys.SBlocks)
+	ddt, lat, bct, dlct := s.DDT(), s.LAT(), s.BCT(), s.DLCT()
+	if ddt[0][0] != s.Size() || lat[0][0] != s.Size()/2 || bct[0][0] != s.Size() || dlct[0][0] != s.Size()/2 {
+		t.Fatalf("zero entries are %d, %d, %d, %d", ddt[0][0], lat[0][0], bct[0][0], dlct[0][0])
+	}
+	for a := 1; a < s.Size(); a++ {
+		sum := 0
+		for b := range ddt[a] {
+			sum += ddt[a][b]
+			// BCT is not less than DDT and is full for zero difference
+			if bct[a][b] < ddt[a][b] || bct[a][0] != s.Size() || bct[0][b] != s.Size() {
+				t.Fatalf("BCT[%d][%d] is %d, DDT is %d", a, b, bct[a][b], ddt[a][b])
+			}
+		}
+		if sum != s.Size() {
+			t.Fatalf("DDT row %d sums to %d", a, sum)
+		}
+	}
+	if present := newSBox(t, PRESENT); present.BoomerangUniformity() != 16 || present.DifferentialLinearUniformity() != 8 {
+		t.Errorf("PRESENT boomerang uniformity is %d and differential-linear uniformity is %d, want 16 and 8",
+			present.BoomerangUniformity(), present.DifferentialLinearUniformity())
+	}
+}
+
+func TestANF(t *testing.T) {
+	s := newSBox(t, heys.SBlocks)
+	anf := s.ANF()
+	for b := 0; b < s.Bits(); b++ {
+		// Möbius transform is an involution
+		f := Mobius(anf[b])
+		for x := 0; x < s.Size(); x++ {
+			if f[x] != (s.Lookup(x)>>uint(b))&1 {
+				t.Fatalf("ANF of coordinate %d gives %d at 0x%x", b, f[x], x)
+			}
+		}
+	}
+	// the only monomial of S(x)=x is x of the coordinate
+	identity := newSBox(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15})
+	if identity.Degree() != 1 {
+		t.Errorf("degree of identity is %d", identity.Degree())
+	}
+	for b, coordinate := range identity.ANF() {
+		for m, v := range coordinate {
+			if want := m == 1<<uint(b); (v == 1) != want {
+				t.Fatalf("coordinate %d of identity has monomial 0x%x = %d", b, m, v)
+			}
+		}
+	}
+}
+
+func TestOptimalClass(t *testing.T) {
+	class, err := newSBox(t, PRESENT).OptimalClass()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if class != 1 {
+		t.Errorf("PRESENT S-box is of class G%d, want G1", class)
+	}
+	for i, values := range OptimalClasses {
+		if class, err := newSBox(t, values).OptimalClass(); err != nil || class != i {
+			t.Errorf("representative of G%d is of class G%d, %v", i, class, err)
+		}
+	}
+	if class, err := newSBox(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}).OptimalClass(); err != nil || class != -1 {
+		t.Errorf("identity is of class G%d, %v", class, err)
+	}
+}
+
+func TestAffineClass(t *testing.T) {
+	for i := 1; i < len(AffineClasses); i++ {
+		if !less(AffineClasses[i-1], AffineClasses[i]) {
+			t.Fatalf("affine classes %d and %d are not in order", i-1, i)
+		}
+	}
+	// representatives are their own representatives, a sample is checked as
+	// every one takes all affine maps
+	for i := 0; i < len(AffineClasses); i += 30 {
+		if class, err := newSBox(t, AffineClasses[i]).AffineClass(); err != nil || class != i {
+			t.Errorf("representative of class %d is of class %d, %v", i, class, err)
+		}
+	}
+	// optimal classes are distinct classes, PRESENT is in class of G1
+	classes := make(map[int]bool)
+	for i, values := range OptimalClasses {
+		class, err := newSBox(t, values).AffineClass()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if classes[class] {
+			t.Errorf("G%d is in class %d of other optimal class", i, class)
+		}
+		classes[class] = true
+		if i == 1 {
+			if present, err := newSBox(t, PRESENT).AffineClass(); err != nil || present != class {
+				t.Errorf("PRESENT is of class %d, G1 of class %d, %v", present, class, err)
+			}
+		}
+	}
+	// B(S(A(x))) is in class of S for affine A(x) = 5x^3 and B(y) = 9y^c in
+	// GF(2^4) with polynomial x^4+x+1
+	want, err := newSBox(t, heys.SBlocks).AffineClass()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mul := func(a, b int) int {
+		p := 0
+		for ; b != 0; b >>= 1 {
+			if b&1 == 1 {
+				p ^= a
+			}
+			if a <<= 1; a&0x10 != 0 {
+				a ^= 0x13
+			}
+		}
+		return p
+	}
+	equivalent := make([]int, 16)
+	for x := range equivalent {
+		equivalent[x] = mul(9, heys.SBlocks[mul(5, x)^3]) ^ 0xc
+	}
+	if class, err := newSBox(t, equivalent).AffineClass(); err != nil || class != want {
+		t.Errorf("affine equivalent of Heys S-box is of class %d, Heys S-box of class %d, %v", class, want, err)
+	}
+	if _, err := newSBox(t, []int{1, 0, 2, 3, 4, 6, 5, 7}).AffineClass(); err == nil {
+		t.Error("3-bit S-box has affine class")
+	}
+}
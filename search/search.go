@@ -0,0 +1,223 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/mariiatuzovska/cryptanalysis/checkpoint"
+	"github.com/mariiatuzovska/cryptanalysis/heys"
+	"github.com/mariiatuzovska/cryptanalysis/progress"
+)
+
+type (
+	// Options are parameters of searches over rounds of SPN, zero fields take
+	// default values
+	Options struct {
+		// Cipher which S-box and SPN are used, heys.DefaultCipher() if nil
+		Cipher *heys.Cipher
+		// Alphas are inputs of search, SingleSBoxBlocks of cipher SPN if empty
+		Alphas []heys.Block
+		// Thresholds are min measures of outputs after every round, the last
+		// threshold is used for remaining rounds, default thresholds of
+		// search if empty
+		Thresholds []float64
+		// Rounds is count of rounds of search, rounds of cipher minus the last
+		// one if zero
+		Rounds int
+		// Workers is count of alphas searched concurrently, runtime.NumCPU()
+		// if zero
+		Workers int
+		// Progress receives count of rounds and alphas done if not nil
+		Progress progress.Func
+		// Checkpoint is path of file where state of search is saved every
+		// CheckpointInterval and on cancellation, it is removed when search
+		// is done, no checkpoints if empty
+		Checkpoint string
+		// CheckpointInterval is one minute if zero
+		CheckpointInterval time.Duration
+		// Resume continues search from Checkpoint if it exists
+		Resume bool
+	}
+	// Terms name inputs and measure of thresholds of a search in errors
+	Terms struct {
+		// Input is name of alphas, e.g. input difference
+		Input string
+		// Measure is name of thresholds, e.g. probability
+		Measure string
+	}
+	// State is state of search in checkpoint file, search parameters are
+	// saved to check that search is resumed with the same options
+	State struct {
+		Width       int          `json:"width"`
+		Count       int          `json:"count"`
+		SBox        []int        `json:"sbox"`
+		Permutation []int        `json:"permutation"`
+		Alphas      []heys.Block `json:"alphas"`
+		Thresholds  []float64    `json:"thresholds"`
+		Rounds      int          `json:"rounds"`
+		Trails      int          `json:"trails,omitempty"`
+		// Done are outputs of finished alphas
+		Done map[heys.Block]map[heys.Block]float64 `json:"done"`
+		// Gamma are outputs of unfinished alphas after some rounds
+		Gamma map[heys.Block]Gamma `json:"gamma"`
+	}
+	// Gamma is measures of outputs after Round rounds
+	Gamma struct {
+		Round int                    `json:"round"`
+		Gamma map[heys.Block]float64 `json:"gamma"`
+	}
+	// Saver saves state of search to checkpoint every CheckpointInterval,
+	// state is shared by workers under lock of Saver
+	Saver struct {
+		sync.Mutex
+		// C ticks when state is to be saved, nil if there are no checkpoints
+		C      <-chan time.Time
+		path   string
+		v      interface{}
+		ticker *time.Ticker
+	}
+)
+
+// WithDefaults returns copy of options with default values of zero fields,
+// thresholds are default thresholds of search
+func (opts Options) WithDefaults(thresholds []float64, terms Terms) (Options, error) {
+	if opts.Cipher == nil {
+		opts.Cipher = heys.DefaultCipher()
+	}
+	if len(opts.Alphas) == 0 {
+		opts.Alphas = opts.Cipher.SPN().SingleSBoxBlocks()
+	}
+	if len(opts.Thresholds) == 0 {
+		opts.Thresholds = thresholds
+	}
+	if opts.Rounds == 0 {
+		opts.Rounds = opts.Cipher.Rounds() - 1
+	}
+	if opts.Workers == 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.CheckpointInterval == 0 {
+		opts.CheckpointInterval = time.Minute
+	}
+	if opts.Rounds < 1 {
+		return opts, fmt.Errorf("search: invalid count of rounds %d", opts.Rounds)
+	}
+	if opts.Workers < 1 {
+		return opts, fmt.Errorf("search: invalid count of workers %d", opts.Workers)
+	}
+	alphas := make(map[heys.Block]bool)
+	for _, alpha := range opts.Alphas {
+		if alpha == 0 || int(alpha) >= opts.Cipher.Size() {
+			return opts, fmt.Errorf("search: invalid %s 0x%04x of %d-bit block", terms.Input, alpha, opts.Cipher.SPN().BlockSize())
+		}
+		if alphas[alpha] {
+			return opts, fmt.Errorf("search: %s 0x%04x repeats", terms.Input, alpha)
+		}
+		alphas[alpha] = true
+	}
+	for _, threshold := range opts.Thresholds {
+		if threshold < 0 || threshold > 1 {
+			return opts, fmt.Errorf("search: threshold %v is not a %s", threshold, terms.Measure)
+		}
+	}
+	return opts, nil
+}
+
+// Threshold returns min measure of outputs after round
+func (opts Options) Threshold(round int) float64 {
+	if round > len(opts.Thresholds) {
+		return opts.Thresholds[len(opts.Thresholds)-1]
+	}
+	return opts.Thresholds[round-1]
+}
+
+// NewState returns empty state of search with opts which keeps trails
+// trails of every output
+func NewState(opts Options, trails int) *State {
+	spn := opts.Cipher.SPN()
+	return &State{
+		Width:       spn.Width(),
+		Count:       spn.Count(),
+		SBox:        opts.Cipher.SBox(),
+		Permutation: spn.Permutation(),
+		Alphas:      opts.Alphas,
+		Thresholds:  opts.Thresholds,
+		Rounds:      opts.Rounds,
+		Trails:      trails,
+		Done:        make(map[heys.Block]map[heys.Block]float64),
+		Gamma:       make(map[heys.Block]Gamma),
+	}
+}
+
+// Load loads v from checkpoint file if opts.Resume is set and the file
+// exists, v is s or a struct which embeds s and keeps more state of search
+func (s *State) Load(opts Options, v interface{}) error {
+	if !opts.Resume || opts.Checkpoint == "" {
+		return nil
+	}
+	want := *s
+	exist, err := checkpoint.Load(opts.Checkpoint, v)
+	if err != nil || !exist {
+		return err
+	}
+	if s.Done == nil {
+		s.Done = make(map[heys.Block]map[heys.Block]float64)
+	}
+	if s.Gamma == nil {
+		s.Gamma = make(map[heys.Block]Gamma)
+	}
+	want.Done, want.Gamma = s.Done, s.Gamma
+	if !reflect.DeepEqual(&want, s) {
+		return fmt.Errorf("search: checkpoint %s is saved by search with other options", opts.Checkpoint)
+	}
+	return nil
+}
+
+// NewSaver returns saver of v to checkpoint of opts, call Stop when search
+// is over
+func NewSaver(opts Options, v interface{}) *Saver {
+	s := &Saver{path: opts.Checkpoint, v: v}
+	if s.path != "" {
+		s.ticker = time.NewTicker(opts.CheckpointInterval)
+		s.C = s.ticker.C
+	}
+	return s
+}
+
+// Save saves state to checkpoint file if there is one
+func (s *Saver) Save() error {
+	if s.path == "" {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	return checkpoint.Save(s.path, s.v)
+}
+
+// Cancel saves state to checkpoint file and returns ctx.Err(), search
+// returns its error when ctx is done
+func (s *Saver) Cancel(ctx context.Context) error {
+	if err := s.Save(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// Remove removes checkpoint file of finished search
+func (s *Saver) Remove() error {
+	if s.path == "" {
+		return nil
+	}
+	return checkpoint.Remove(s.path)
+}
+
+// Stop stops ticks of saver
+func (s *Saver) Stop() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+}
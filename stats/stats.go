@@ -0,0 +1,26 @@
+package stats
+
+import "math"
+
+const (
+	// Keys is default count of random keys of measurements
+	Keys = 100
+	// Z is quantile of normal distribution of 95% confidence interval
+	Z = 1.96
+)
+
+// MeanInterval returns mean of values and bounds of its 95% confidence
+// interval, the low bound is clamped at zero, there are at least two values
+func MeanInterval(values []float64) (float64, float64, float64) {
+	mean, variance := 0.0, 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values) - 1)
+	e := Z * math.Sqrt(variance/float64(len(values)))
+	return mean, math.Max(mean-e, 0), mean + e
+}